@@ -0,0 +1,265 @@
+package imageboss
+
+import (
+	"html/template"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Breakpoint is one crop of an art-directed image: the operation, options and
+// srcset widths used when Media matches.
+type Breakpoint struct {
+	// Media is the condition for this crop. The last breakpoint has none and
+	// becomes the <img> fallback.
+	Media     MediaCondition
+	Operation Operation
+	Options   []Option
+	// MinWidth and MaxWidth give a fluid (width-based) srcset between them,
+	// with each entry from Operation.ResizeToWidth, so cover crops keep their
+	// aspect ratio. Unset, the srcset is as CreateSrcset makes it.
+	MinWidth, MaxWidth int
+	// Sizes is the sizes attribute of a fluid srcset (100vw if unset).
+	Sizes Sizes
+}
+
+// PictureData is a rendered <picture> element as data, for frontends that
+// build their own markup. It marshals to JSON with lower-case keys.
+type PictureData struct {
+	Sources []PictureSource `json:"sources"`
+	Img     PictureImg      `json:"img"`
+}
+
+// PictureSource is a <source> element.
+type PictureSource struct {
+	Media  string `json:"media,omitempty"`
+	Type   string `json:"type,omitempty"`
+	Srcset string `json:"srcset"`
+	Sizes  string `json:"sizes,omitempty"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+}
+
+// PictureImg is the <img> fallback of a <picture> element.
+type PictureImg struct {
+	Src    string `json:"src"`
+	Srcset string `json:"srcset,omitempty"`
+	Sizes  string `json:"sizes,omitempty"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+	Alt    string `json:"alt"`
+
+	Loading       string            `json:"loading,omitempty"`
+	Decoding      string            `json:"decoding,omitempty"`
+	FetchPriority string            `json:"fetchpriority,omitempty"`
+	Attrs         map[string]string `json:"attrs,omitempty"` // from WithAttr
+}
+
+// ArtDirection builds an art-directed <picture> for path: one <source media=…>
+// per breakpoint with a media condition, in order, and an <img> for the last
+// breakpoint, which must have none:
+//
+//	pic, err := b.ArtDirection("hero.jpg", []imageboss.Breakpoint{
+//		{Media: imageboss.MediaMinWidth(1024), Operation: imageboss.CoverAspectMode(2100, "21:9", "smart"), MinWidth: 1024, MaxWidth: 2560},
+//		{Operation: imageboss.CoverMode(800, 800, "face"), MinWidth: 320, MaxWidth: 1600},
+//	}, imageboss.WithAlt("Our team"))
+//	html := pic.HTML()
+//
+// Of opts, WithSrcset applies to every breakpoint, WithVariant adds a source
+// per breakpoint and variant, with both media conditions, ahead of the
+// breakpoint's own, and the rest apply to the <img>.
+// It validates its arguments as BuildImg does and returns a *ValidationError
+// wrapping ErrInvalidBreakpoint for a missing or misplaced fallback.
+func (b *URLBuilder) ArtDirection(path string, breakpoints []Breakpoint, opts ...ImgOption) (*PictureData, error) {
+	if len(breakpoints) == 0 {
+		return nil, invalid(ErrInvalidBreakpoint, "breakpoints", nil, "at least one is required")
+	}
+	c := newImgConfig(opts)
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	b = b.snapshot()
+	last := len(breakpoints) - 1
+	for i, bp := range breakpoints {
+		field := "breakpoints[" + strconv.Itoa(i) + "]"
+		switch {
+		case i < last && bp.Media.text == "":
+			return nil, invalid(ErrInvalidBreakpoint, field, nil, "only the last breakpoint may have no media condition")
+		case i == last && bp.Media.text != "":
+			return nil, invalid(ErrInvalidBreakpoint, field, bp.Media.text, "the last breakpoint is the fallback and must have no media condition")
+		}
+		if bp.MinWidth != 0 || bp.MaxWidth != 0 {
+			if _, err := validateRangeWithTolerance(bp.MinWidth, bp.MaxWidth, defaultTolerance); err != nil {
+				return nil, err
+			}
+			if err := validateDimension("maxWidth", bp.MaxWidth); err != nil {
+				return nil, err
+			}
+			if err := bp.Sizes.validate(); err != nil {
+				return nil, err
+			}
+		}
+		if err := validateSrcsetArgs(path, bp.Operation, bp.Options, newSrcsetOptions(b.config().srcsetDefaults, c.srcsetOpts)); err != nil {
+			return nil, err
+		}
+		for _, v := range c.variants {
+			if err := v.validate(path, bp.Operation, bp.Options); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	o := newSrcsetOptions(b.config().srcsetDefaults, c.srcsetOpts)
+	p := &PictureData{Sources: make([]PictureSource, 0, len(breakpoints)*(len(c.variants)+1)-1)}
+	source := func(path string, bp Breakpoint) error {
+		srcset, sizes, err := b.breakpointSrcset(path, bp, c)
+		w, h := imgDimensions(bp.Operation, o.IntrinsicWidth, o.IntrinsicHeight)
+		p.Sources = append(p.Sources, PictureSource{
+			Media:  bp.Media.String(),
+			Srcset: srcset.Compact(),
+			Sizes:  sizes,
+			Width:  dimension(w, h),
+			Height: dimension(h, w),
+		})
+		return err
+	}
+	for i, bp := range breakpoints {
+		// Variants of a breakpoint come first, so they win when both match.
+		for _, v := range c.variants {
+			vbp := bp
+			vbp.Media = bp.Media.And(v.Media)
+			var vpath string
+			vpath, vbp.Operation, vbp.Options = v.apply(path, bp.Operation, bp.Options)
+			if err := source(vpath, vbp); err != nil {
+				return nil, err
+			}
+		}
+		if i < last {
+			if err := source(path, bp); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	bp := breakpoints[last]
+	srcset, sizes, err := b.breakpointSrcset(path, bp, c)
+	if err != nil {
+		return nil, err
+	}
+	src, err := b.config().createURL(path, bp.Operation, bp.Options)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range srcset {
+		if e.Density == 1 {
+			src = e.URL
+			break
+		}
+	}
+	if sizes != "" {
+		e, _ := srcset.ClosestTo(c.fallbackWidth)
+		src = e.URL
+	}
+	w, h := imgDimensions(bp.Operation, o.IntrinsicWidth, o.IntrinsicHeight)
+	p.Img = PictureImg{
+		Src:           src,
+		Srcset:        srcset.Compact(),
+		Sizes:         sizes,
+		Width:         dimension(w, h),
+		Height:        dimension(h, w),
+		Alt:           c.alt,
+		Loading:       c.loading,
+		Decoding:      c.decoding,
+		FetchPriority: c.fetchPriority,
+	}
+	for _, a := range c.attrs {
+		if p.Img.Attrs == nil {
+			p.Img.Attrs = make(map[string]string)
+		}
+		p.Img.Attrs[a[0]] = a[1]
+	}
+	return p, nil
+}
+
+// breakpointSrcset returns the srcset for bp and, for a fluid srcset, its
+// sizes. On a signing error it also returns the error.
+func (b *URLBuilder) breakpointSrcset(path string, bp Breakpoint, c imgConfig) (Srcset, string, error) {
+	var srcset Srcset
+	var err error
+	opts := newSrcsetOptions(b.config().srcsetDefaults, c.srcsetOpts)
+	if bp.MinWidth != 0 || bp.MaxWidth != 0 {
+		widths := TargetWidths(bp.MinWidth, bp.MaxWidth, opts.Tolerance)
+		srcset, err = b.srcsetFromWidths(path, bp.Operation, bp.Options, widths, opts)
+	} else {
+		srcset, err = b.srcset(path, bp.Operation, bp.Options, opts)
+	}
+	if len(srcset) == 0 || srcset[0].Width == 0 {
+		return srcset, "", err
+	}
+	return srcset, bp.Sizes.String(), err
+}
+
+// dimension returns d if both d and other are known, so width and height are
+// rendered together or not at all.
+func dimension(d, other int) int {
+	if d > 0 && other > 0 {
+		return d
+	}
+	return 0
+}
+
+// HTML renders p as a <picture> element. Attribute values are HTML-escaped;
+// invalid attributes, as BuildImg defines them, are left out, and Attrs are
+// rendered in name order.
+func (p *PictureData) HTML() template.HTML {
+	var sb strings.Builder
+	sb.WriteString("<picture>\n")
+	for _, s := range p.Sources {
+		sb.WriteString("<source")
+		if s.Media != "" {
+			writeAttr(&sb, "media", s.Media)
+		}
+		if s.Type != "" {
+			writeAttr(&sb, "type", s.Type)
+		}
+		writeAttr(&sb, "srcset", s.Srcset)
+		if s.Sizes != "" {
+			writeAttr(&sb, "sizes", s.Sizes)
+		}
+		if s.Width > 0 && s.Height > 0 {
+			writeAttr(&sb, "width", strconv.Itoa(s.Width))
+			writeAttr(&sb, "height", strconv.Itoa(s.Height))
+		}
+		sb.WriteString(">\n")
+	}
+	sb.WriteString("<img")
+	writeAttr(&sb, "src", p.Img.Src)
+	if p.Img.Srcset != "" {
+		writeAttr(&sb, "srcset", p.Img.Srcset)
+	}
+	if p.Img.Sizes != "" {
+		writeAttr(&sb, "sizes", p.Img.Sizes)
+	}
+	if p.Img.Width > 0 && p.Img.Height > 0 {
+		writeAttr(&sb, "width", strconv.Itoa(p.Img.Width))
+		writeAttr(&sb, "height", strconv.Itoa(p.Img.Height))
+	}
+	writeAttr(&sb, "alt", p.Img.Alt)
+	for _, a := range [][2]string{{"loading", p.Img.Loading}, {"decoding", p.Img.Decoding}, {"fetchpriority", p.Img.FetchPriority}} {
+		if a[1] != "" && attrValues[a[0]][a[1]] {
+			writeAttr(&sb, a[0], a[1])
+		}
+	}
+	names := make([]string, 0, len(p.Img.Attrs))
+	for name := range p.Img.Attrs {
+		if validateAttrName(name) == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writeAttr(&sb, name, p.Img.Attrs[name])
+	}
+	sb.WriteString(">\n</picture>")
+	return template.HTML(sb.String())
+}
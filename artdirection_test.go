@@ -0,0 +1,114 @@
+package imageboss
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func heroBreakpoints() []Breakpoint {
+	return []Breakpoint{
+		{
+			Media:     MediaMinWidth(1024),
+			Operation: CoverAspectMode(2100, "21:9", "smart"),
+			Options:   []Option{FormatAuto()},
+			MinWidth:  1024, MaxWidth: 1400,
+			Sizes: Sizes{}.Default(VW(100)),
+		},
+		{
+			Operation: CoverMode(800, 800, "face"),
+			MinWidth:  320, MaxWidth: 400,
+			Sizes: Sizes{}.Default(VW(100)),
+		},
+	}
+}
+
+func TestArtDirection(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	p, err := b.ArtDirection("hero.jpg", heroBreakpoints(), WithAlt("Team"), WithLoading("eager"), WithAttr("class", "hero"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Sources) != 1 {
+		t.Fatalf("got %d sources; want 1", len(p.Sources))
+	}
+	s := p.Sources[0]
+	if s.Media != "(min-width: 1024px)" || s.Sizes != "100vw" || s.Width != 2100 || s.Height != 900 {
+		t.Errorf("source = %+v", s)
+	}
+	if !strings.HasPrefix(s.Srcset, "https://img.imageboss.me/demo/cover:smart/1024x439/format:auto/hero.jpg 1024w, ") ||
+		!strings.HasSuffix(s.Srcset, "https://img.imageboss.me/demo/cover:smart/1400x600/format:auto/hero.jpg 1400w") {
+		t.Errorf("source srcset = %s", s.Srcset)
+	}
+	img := p.Img
+	if img.Src != "https://img.imageboss.me/demo/cover:face/400x400/hero.jpg" || img.Width != 800 || img.Height != 800 || img.Alt != "Team" {
+		t.Errorf("img = %+v", img)
+	}
+	if !strings.Contains(img.Srcset, "/cover:face/320x320/hero.jpg 320w") {
+		t.Errorf("img srcset = %s", img.Srcset)
+	}
+
+	html := string(p.HTML())
+	want := "<picture>\n" +
+		`<source media="(min-width: 1024px)" srcset="` + s.Srcset + `" sizes="100vw" width="2100" height="900">` + "\n" +
+		`<img src="` + img.Src + `" srcset="` + img.Srcset + `" sizes="100vw" width="800" height="800" alt="Team" loading="eager" class="hero">` + "\n" +
+		"</picture>"
+	if html != want {
+		t.Errorf("\ngot:  %s\nwant: %s", html, want)
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded PictureData
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.HTML() != p.HTML() || !strings.Contains(string(data), `"media":"(min-width: 1024px)"`) {
+		t.Errorf("JSON round trip changed the picture:\n%s", data)
+	}
+}
+
+func TestArtDirection_DensityFallback(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	p, err := b.ArtDirection("a.jpg", []Breakpoint{
+		{Media: MediaMaxWidth(600), Operation: Cover(300, 300)},
+		{Operation: Width(600)},
+	}, WithSrcset(WithDensities(1, 2), WithVariableQuality(false)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Sources[0].Sizes != "" || !strings.HasSuffix(p.Sources[0].Srcset, "/cover/300x300/dpr:2/a.jpg 2x") {
+		t.Errorf("source = %+v", p.Sources[0])
+	}
+	if p.Img.Src != "https://img.imageboss.me/demo/width/600/a.jpg" || p.Img.Width != 0 {
+		t.Errorf("img = %+v", p.Img)
+	}
+}
+
+func TestArtDirection_Invalid(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	tests := []struct {
+		name        string
+		breakpoints []Breakpoint
+		opts        []ImgOption
+		want        error
+	}{
+		{"none", nil, nil, ErrInvalidBreakpoint},
+		{"no fallback", []Breakpoint{{Media: MediaMinWidth(800), Operation: Width(800)}}, nil, ErrInvalidBreakpoint},
+		{"fallback first", []Breakpoint{{Operation: Width(800)}, {Media: MediaMinWidth(800), Operation: Width(800)}}, nil, ErrInvalidBreakpoint},
+		{"bad operation", []Breakpoint{{Operation: CoverAspect(800, "wide")}}, nil, ErrInvalidAspectRatio},
+		{"bad range", []Breakpoint{{Operation: CDN(), MinWidth: 500, MaxWidth: 100}}, nil, ErrInvalidWidthRange},
+		{"bad sizes", []Breakpoint{{Operation: CDN(), MinWidth: 100, MaxWidth: 500, Sizes: Sizes{}.Default(Px(-5))}}, nil, ErrInvalidSizes},
+		{"bad attribute", []Breakpoint{{Operation: CDN()}}, []ImgOption{WithDecoding("later")}, ErrInvalidAttribute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := b.ArtDirection("a.jpg", tt.breakpoints, tt.opts...); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v; want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -9,7 +9,9 @@
 package imageboss
 
 import (
+	"fmt"
 	"strings"
+	"sync/atomic"
 )
 
 const (
@@ -21,31 +23,58 @@ const (
 )
 
 // URLBuilder builds ImageBoss image URLs.
+//
+// A URLBuilder is safe for concurrent use. Its configuration is an immutable
+// snapshot: every method reads the snapshot once, and the setters (SetSecret,
+// SetUseHTTPS, Reconfigure) atomically replace it, so a URL is always built
+// from one consistent configuration. Use With to derive a modified copy
+// without affecting the original.
+//
+// Methods such as CreateURL, CreateSrcset and Img never fail: they render what
+// they are given, and URLs the Signer could not sign are left without a
+// bossToken. The methods that return an error (BuildURL, BuildSrcset, BuildImg,
+// ArtDirection and so on) validate their arguments first and also report
+// Signer errors.
 type URLBuilder struct {
-	baseURL  string
-	source   string
-	useHTTPS bool
-	secret   string // optional; when set, URLs are signed with bossToken (HMAC SHA-256 of path)
+	cfg atomic.Pointer[builderConfig]
+}
+
+// builderConfig is the configuration of a URLBuilder. It is never modified
+// once stored; changes are made on a copy.
+type builderConfig struct {
+	baseURL string // as configured, e.g. "https://img.imageboss.me"
+	host    string // host and optional path prefix of baseURL, without scheme
+	scheme  string // "https", "http", or "" for protocol-relative URLs
+	source  string
+	signer  Signer // optional; when set, URLs are signed with bossToken
+
+	previousSecrets []retiredSecret // also accepted by Verify until they expire
+	defaultOptions  []Option        // added to every URL
+	srcsetDefaults  []SrcsetOption  // applied before per-call srcset options
+	canonical       bool            // emit options in CanonicalOptions order
 }
 
 // BuilderOption configures a URLBuilder.
-type BuilderOption func(*URLBuilder)
+type BuilderOption func(*builderConfig)
 
 // NewURLBuilder creates a URLBuilder for the given ImageBoss source.
 // The source is the name configured in your ImageBoss dashboard (e.g. "mywebsite-images").
+// It returns an error if the source or the base URL is invalid.
 func NewURLBuilder(source string, options ...BuilderOption) (*URLBuilder, error) {
 	source, err := validateSource(source)
 	if err != nil {
 		return nil, err
 	}
-	b := &URLBuilder{
-		baseURL:  DefaultBaseURL,
-		source:   source,
-		useHTTPS: true,
-	}
-	for _, fn := range options {
-		fn(b)
+	c, err := builderConfig{
+		baseURL: DefaultBaseURL,
+		scheme:  "https",
+		source:  source,
+	}.with(options)
+	if err != nil {
+		return nil, err
 	}
+	b := &URLBuilder{}
+	b.cfg.Store(c)
 	return b, nil
 }
 
@@ -58,57 +87,248 @@ func MustNewURLBuilder(source string, options ...BuilderOption) *URLBuilder {
 	return b
 }
 
+// with returns a copy of c with options applied and validated.
+func (c builderConfig) with(options []BuilderOption) (*builderConfig, error) {
+	for _, fn := range options {
+		fn(&c)
+	}
+	host, err := validateBaseURL(c.baseURL)
+	if err != nil {
+		return nil, err
+	}
+	c.host = host
+	return &c, nil
+}
+
+// config returns the current configuration snapshot.
+func (b *URLBuilder) config() *builderConfig {
+	return b.cfg.Load()
+}
+
+// Derive returns a new URLBuilder with the receiver's configuration and options
+// applied. The receiver is not modified.
+func (b *URLBuilder) Derive(options ...BuilderOption) (*URLBuilder, error) {
+	c, err := b.config().with(options)
+	if err != nil {
+		return nil, err
+	}
+	d := &URLBuilder{}
+	d.cfg.Store(c)
+	return d, nil
+}
+
+// With is like Derive but panics if the options are invalid (e.g. a bad base URL).
+// It is intended for options known to be valid, such as b.With(WithSecret(s)).
+func (b *URLBuilder) With(options ...BuilderOption) *URLBuilder {
+	d, err := b.Derive(options...)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// Reconfigure atomically applies options to the builder. Concurrent calls to
+// CreateURL and other methods see either the old or the new configuration,
+// never a mix. If the options are invalid the builder is left unchanged.
+func (b *URLBuilder) Reconfigure(options ...BuilderOption) error {
+	for {
+		old := b.config()
+		c, err := old.with(options)
+		if err != nil {
+			return err
+		}
+		if b.cfg.CompareAndSwap(old, c) {
+			return nil
+		}
+	}
+}
+
+// snapshot returns a builder pinned to the current configuration, so results
+// made of several URLs (such as srcsets) are built from one configuration.
+func (b *URLBuilder) snapshot() *URLBuilder {
+	s := &URLBuilder{}
+	s.cfg.Store(b.config())
+	return s
+}
+
+// update is Reconfigure for options that cannot fail validation.
+func (b *URLBuilder) update(option BuilderOption) {
+	if err := b.Reconfigure(option); err != nil {
+		panic(err) // unreachable: the base URL was validated when the builder was created
+	}
+}
+
 // WithBaseURL sets a custom base URL (e.g. for testing or custom CDN).
+// The base URL may include a path prefix. Its scheme (http, https, or none for
+// "//host") becomes the scheme of generated URLs unless a later WithHTTPS or
+// WithProtocolRelative overrides it.
 func WithBaseURL(baseURL string) BuilderOption {
-	return func(b *URLBuilder) {
-		b.baseURL = strings.TrimSuffix(baseURL, "/")
+	return func(c *builderConfig) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+		if scheme, _, ok := strings.Cut(c.baseURL, "://"); ok {
+			c.scheme = strings.ToLower(scheme)
+		} else if strings.HasPrefix(c.baseURL, "//") {
+			c.scheme = ""
+		}
 	}
 }
 
-// WithHTTPS sets whether to use HTTPS in generated URLs.
+// WithHTTPS sets whether to use HTTPS in generated URLs. It rewrites the scheme
+// of the base URL.
 func WithHTTPS(useHTTPS bool) BuilderOption {
-	return func(b *URLBuilder) {
-		b.useHTTPS = useHTTPS
+	return func(c *builderConfig) {
+		if useHTTPS {
+			c.scheme = "https"
+		} else {
+			c.scheme = "http"
+		}
 	}
 }
 
-// SetUseHTTPS sets whether to use HTTPS.
+// SetUseHTTPS sets whether to use HTTPS. See WithHTTPS.
+// It is safe to call while other goroutines use the builder.
 func (b *URLBuilder) SetUseHTTPS(use bool) {
-	b.useHTTPS = use
+	b.update(WithHTTPS(use))
+}
+
+// WithProtocolRelative makes generated URLs protocol-relative
+// (e.g. "//img.imageboss.me/..."), for templates that serve pages over both
+// HTTP and HTTPS. A later WithHTTPS switches back to an explicit scheme.
+func WithProtocolRelative() BuilderOption {
+	return func(c *builderConfig) {
+		c.scheme = ""
+	}
 }
 
 // WithSecret sets the secret token for signing URLs. When set, generated URLs
 // include a bossToken query parameter (HMAC SHA-256 of the path). Enable
 // "I want extra security. My URLs need to be signed" for the source in the
 // ImageBoss dashboard to get the secret. See https://imageboss.me/docs/security.
+// An empty secret turns signing off.
 func WithSecret(secret string) BuilderOption {
-	return func(b *URLBuilder) {
-		b.secret = secret
+	return func(c *builderConfig) {
+		c.signer = nil
+		if secret != "" {
+			c.signer = HMACSigner(secret)
+		}
 	}
 }
 
 // SetSecret sets the secret for signing URLs. See WithSecret.
+// It is safe to call while other goroutines use the builder.
 func (b *URLBuilder) SetSecret(secret string) {
-	b.secret = secret
+	b.update(WithSecret(secret))
+}
+
+// WithSigner sets the Signer that produces bossTokens, replacing any secret set
+// with WithSecret. Use it to sign through an external key service or to inject
+// a deterministic signer in tests. A nil Signer turns signing off.
+func WithSigner(s Signer) BuilderOption {
+	return func(c *builderConfig) {
+		c.signer = s
+	}
+}
+
+// WithDefaultOptions adds options to every URL the builder creates, including
+// srcset entries. They come before the per-call options; a per-call option
+// with the same key (e.g. Quality for a default Quality) replaces the default
+// instead of adding a second segment, and NoDefaults leaves them out for one
+// call. Repeated WithDefaultOptions calls accumulate.
+func WithDefaultOptions(options ...Option) BuilderOption {
+	return func(c *builderConfig) {
+		c.defaultOptions = append(append([]Option(nil), c.defaultOptions...), options...)
+	}
+}
+
+// WithSrcsetDefaults sets srcset options (e.g. WithMaxWidth(2000)) applied to
+// every CreateSrcset and BuildSrcset call before the per-call srcset options.
+// Repeated WithSrcsetDefaults calls accumulate.
+func WithSrcsetDefaults(options ...SrcsetOption) BuilderOption {
+	return func(c *builderConfig) {
+		c.srcsetDefaults = append(append([]SrcsetOption(nil), c.srcsetDefaults...), options...)
+	}
 }
 
 // Source returns the configured source name.
 func (b *URLBuilder) Source() string {
-	return b.source
+	return b.config().source
 }
 
-// BaseURL returns the base URL (without path).
+// BaseURL returns the base URL (without image path) with the configured scheme
+// applied, e.g. "https://img.imageboss.me" or "//img.imageboss.me".
 func (b *URLBuilder) BaseURL() string {
-	return b.baseURL
+	return b.config().prefix()
+}
+
+// prefix returns the base URL with the configured scheme applied.
+func (c *builderConfig) prefix() string {
+	if c.scheme == "" {
+		return "//" + c.host
+	}
+	return c.scheme + "://" + c.host
 }
 
 // CreateURL builds an ImageBoss URL for the given image path, operation, and options.
 // Path is the image path relative to your source (e.g. "examples/02.jpg").
 // Operation is one of: CDN(), Width(w), Height(h), Cover(w, h), or CoverMode(w, h, mode).
 // Options are path-segment options like Opt("blur", "4") or Opt("format", "auto").
+// If the builder's Signer fails, the URL is returned without a bossToken; use
+// BuildURL to get the error instead.
 func (b *URLBuilder) CreateURL(path string, op Operation, options ...Option) string {
-	path = sanitizePath(path)
-	segments := []string{strings.TrimSuffix(b.baseURL, "/"), b.source, op.PathSegment()}
+	urlStr, _ := b.config().createURL(path, op, options)
+	return urlStr
+}
+
+// createURL builds the URL and signs it if a Signer is configured. On a signing
+// error it returns the unsigned URL and the error.
+func (c *builderConfig) createURL(path string, op Operation, options []Option) (string, error) {
+	options = mergeOptions(c.defaultOptions, options)
+	if c.canonical {
+		options = CanonicalOptions(options...)
+	}
+	segments := urlSegments(c.source, op, options, sanitizePath(path))
+	urlStr := c.prefix() + "/" + strings.Join(segments, "/")
+	if c.signer != nil {
+		pathForSigning := "/" + strings.Join(segments, "/")
+		token, err := c.signer.Sign(pathForSigning)
+		if err != nil {
+			return urlStr, fmt.Errorf("imageboss: signing %s: %w", pathForSigning, err)
+		}
+		urlStr += "?bossToken=" + token
+	}
+	return urlStr, nil
+}
+
+// BuildURL is like CreateURL but validates its arguments first. It returns an
+// error if path is empty, op fails Operation.Validate, an option fails
+// ValidateOptions, or the Signer fails; use errors.Is with the package's Err
+// values to inspect it.
+func (b *URLBuilder) BuildURL(path string, op Operation, options ...Option) (string, error) {
+	if err := validateURLArgs(path, op, options); err != nil {
+		return "", err
+	}
+	urlStr, err := b.config().createURL(path, op, options)
+	if err != nil {
+		return "", err
+	}
+	return urlStr, nil
+}
+
+// validateURLArgs validates the arguments shared by BuildURL and BuildSrcset.
+func validateURLArgs(path string, op Operation, options []Option) error {
+	if err := validatePath(path); err != nil {
+		return err
+	}
+	if err := validateOperation(op); err != nil {
+		return err
+	}
+	return ValidateOptions(options...)
+}
+
+// urlSegments returns the path segments after the base URL:
+// source, operation, dimensions (if any), options and the escaped image path.
+func urlSegments(source string, op Operation, options []Option, escapedPath string) []string {
+	segments := []string{source, op.PathSegment()}
 	if d := op.Dimensions(); d != "" {
 		segments = append(segments, d)
 	}
@@ -117,13 +337,7 @@ func (b *URLBuilder) CreateURL(path string, op Operation, options ...Option) str
 			segments = append(segments, s)
 		}
 	}
-	segments = append(segments, path)
-	urlStr := strings.Join(segments, "/")
-	if b.secret != "" {
-		pathForSigning := "/" + strings.Join(segments[1:], "/")
-		urlStr += "?bossToken=" + signPath(b.secret, pathForSigning)
-	}
-	return urlStr
+	return append(segments, escapedPath)
 }
 
 // CreateURLWithParams builds a URL with CDN operation and the given options.
@@ -1,7 +1,10 @@
 package imageboss
 
 import (
+	"errors"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -124,3 +127,159 @@ func TestCreateURL_Signed(t *testing.T) {
 	}
 }
 
+func TestCreateURL_HTTPS(t *testing.T) {
+	tests := []struct {
+		opts []BuilderOption
+		want string
+	}{
+		{nil, "https://img.imageboss.me/demo/cdn/img.jpg"},
+		{[]BuilderOption{WithHTTPS(false)}, "http://img.imageboss.me/demo/cdn/img.jpg"},
+		{[]BuilderOption{WithBaseURL("http://localhost:8080")}, "http://localhost:8080/demo/cdn/img.jpg"},
+		{[]BuilderOption{WithBaseURL("http://localhost:8080"), WithHTTPS(true)}, "https://localhost:8080/demo/cdn/img.jpg"},
+		{[]BuilderOption{WithHTTPS(false), WithBaseURL("https://cdn.example.com/")}, "https://cdn.example.com/demo/cdn/img.jpg"},
+		{[]BuilderOption{WithProtocolRelative()}, "//img.imageboss.me/demo/cdn/img.jpg"},
+		{[]BuilderOption{WithBaseURL("//cdn.example.com/prefix")}, "//cdn.example.com/prefix/demo/cdn/img.jpg"},
+	}
+	for _, tt := range tests {
+		b := MustNewURLBuilder("demo", tt.opts...)
+		if got := b.CreateURL("img.jpg", CDN()); got != tt.want {
+			t.Errorf("\ngot:  %s\nwant: %s", got, tt.want)
+		}
+	}
+
+	b := MustNewURLBuilder("demo")
+	b.SetUseHTTPS(false)
+	if got, want := b.BaseURL(), "http://img.imageboss.me"; got != want {
+		t.Errorf("BaseURL() after SetUseHTTPS(false) = %s; want %s", got, want)
+	}
+}
+
+func TestNewURLBuilder_InvalidBaseURL(t *testing.T) {
+	invalid := []string{"", "img.imageboss.me", "ftp://img.imageboss.me", "https://", "https://cdn.example.com/?q=1", "://bad"}
+	for _, u := range invalid {
+		if _, err := NewURLBuilder("demo", WithBaseURL(u)); err == nil {
+			t.Errorf("NewURLBuilder(WithBaseURL(%q)) expected error", u)
+		}
+	}
+}
+
+func TestBuildURL(t *testing.T) {
+	b := MustNewURLBuilder("mywebsite-images")
+	got, err := b.BuildURL("examples/02.jpg", Width(700), Blur(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := b.CreateURL("examples/02.jpg", Width(700), Blur(4)); got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+
+	tests := []struct {
+		path string
+		op   Operation
+		opts []Option
+		want error
+	}{
+		{"", CDN(), nil, ErrEmptyPath},
+		{" / ", CDN(), nil, ErrEmptyPath},
+		{"img.jpg", Width(0), nil, ErrInvalidDimension},
+		{"img.jpg", Cover(-5, 300), nil, ErrInvalidDimension},
+		{"img.jpg", CoverMode(300, 300, "middle"), nil, ErrUnknownCoverMode},
+		{"img.jpg", Width(700), []Option{Blur(50)}, ErrInvalidOption},
+	}
+	for _, tt := range tests {
+		got, err := b.BuildURL(tt.path, tt.op, tt.opts...)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("BuildURL(%q, %+v) err = %v; want %v", tt.path, tt.op, err, tt.want)
+		}
+		if got != "" {
+			t.Errorf("BuildURL(%q, %+v) = %q; want empty on error", tt.path, tt.op, got)
+		}
+	}
+}
+
+func TestURLBuilder_With(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	signed := b.With(WithSecret("mysecret"), WithHTTPS(false))
+	if got := b.CreateURL("img.jpg", CDN()); got != "https://img.imageboss.me/demo/cdn/img.jpg" {
+		t.Errorf("With modified the original builder: %s", got)
+	}
+	got := signed.CreateURL("img.jpg", CDN())
+	if !strings.HasPrefix(got, "http://img.imageboss.me/demo/cdn/img.jpg?bossToken=") {
+		t.Errorf("derived builder URL = %s", got)
+	}
+	if _, err := b.Derive(WithBaseURL("ftp://bad")); !errors.Is(err, ErrInvalidBaseURL) {
+		t.Errorf("Derive(bad base URL) err = %v; want ErrInvalidBaseURL", err)
+	}
+}
+
+func TestURLBuilder_Reconfigure(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	if err := b.Reconfigure(WithBaseURL("https://cdn.example.com")); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.BaseURL(), "https://cdn.example.com"; got != want {
+		t.Errorf("BaseURL() = %s; want %s", got, want)
+	}
+	if err := b.Reconfigure(WithBaseURL("no-host")); err == nil {
+		t.Error("expected error for invalid base URL")
+	}
+	if got, want := b.BaseURL(), "https://cdn.example.com"; got != want {
+		t.Errorf("BaseURL() after failed Reconfigure = %s; want %s", got, want)
+	}
+}
+
+func TestURLBuilder_Concurrent(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				b.SetSecret(strconv.Itoa(j))
+				b.SetUseHTTPS(j%2 == 0)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				u := b.CreateURL("img.jpg", Width(100))
+				if _, err := ParseURL(u); err != nil {
+					t.Errorf("ParseURL(%s) = %v", u, err)
+				}
+				_ = b.CreateSrcset("img.jpg", CDN(), nil)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCreateURL_DefaultOptions(t *testing.T) {
+	b := MustNewURLBuilder("demo", WithDefaultOptions(FormatAuto(), Quality(80)))
+	tests := []struct {
+		name    string
+		options []Option
+		want    string
+	}{
+		{"defaults", nil, "https://img.imageboss.me/demo/width/300/format:auto/quality:80/a.jpg"},
+		{"added", []Option{Blur(2)}, "https://img.imageboss.me/demo/width/300/format:auto/quality:80/blur:2/a.jpg"},
+		{"override", []Option{Quality(50)}, "https://img.imageboss.me/demo/width/300/format:auto/quality:50/a.jpg"},
+		{"opt out", []Option{Download(), NoDefaults()}, "https://img.imageboss.me/demo/width/300/download:1/a.jpg"},
+		{"opt out by key", []Option{NoDefaults("format")}, "https://img.imageboss.me/demo/width/300/quality:80/a.jpg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.CreateURL("a.jpg", Width(300), tt.options...); got != tt.want {
+				t.Errorf("\ngot:  %s\nwant: %s", got, tt.want)
+			}
+		})
+	}
+
+	srcset := b.CreateSrcset("a.jpg", Width(300), []Option{Quality(60)}, WithVariableQuality(false))
+	if strings.Contains(srcset, "quality:80") || !strings.Contains(srcset, "/format:auto/quality:60/a.jpg 1x") {
+		t.Errorf("srcset does not merge defaults:\n%s", srcset)
+	}
+	if got := b.Image("a.jpg").Width(300).NoDefaults("quality").URL(); got != "https://img.imageboss.me/demo/width/300/format:auto/a.jpg" {
+		t.Errorf("ImageRequest.NoDefaults: got %s", got)
+	}
+}
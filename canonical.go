@@ -0,0 +1,78 @@
+package imageboss
+
+import (
+	"sort"
+	"strings"
+)
+
+// WithCanonicalOptions makes the builder emit options in canonical order (see
+// CanonicalOptions), so the same image and options always produce the same
+// URL and hit the same CDN cache entry regardless of call order.
+func WithCanonicalOptions(enabled bool) BuilderOption {
+	return func(c *builderConfig) {
+		c.canonical = enabled
+	}
+}
+
+// CanonicalOptions returns options sorted by key, keeping only the last option
+// for each key and dropping options that render an empty segment or an empty
+// value (e.g. Param("") or Param("blur", "")).
+func CanonicalOptions(options ...Option) []Option {
+	last := make(map[string]int, len(options))
+	for i, o := range options {
+		seg := o.PathSegment()
+		if seg == "" || strings.HasSuffix(seg, ":") {
+			continue
+		}
+		last[optionKey(o)] = i
+	}
+	canonical := make([]Option, 0, len(last))
+	for i, o := range options {
+		if j, ok := last[optionKey(o)]; ok && j == i {
+			canonical = append(canonical, o)
+		}
+	}
+	sort.Slice(canonical, func(i, j int) bool {
+		return optionKey(canonical[i]) < optionKey(canonical[j])
+	})
+	return canonical
+}
+
+// CanonicalKey returns a cache key for an ImageBoss URL: its host and path
+// with options in canonical order (see CanonicalOptions), without the scheme
+// or bossToken. URLs that differ only in option order, repeated options or
+// signature have the same key:
+//
+//	CanonicalKey("https://img.imageboss.me/demo/width/300/format:auto/blur:4/a.jpg")
+//	// "img.imageboss.me/demo/width/300/blur:4/format:auto/a.jpg"
+//
+// It returns the ParseURL error for a URL that is not an ImageBoss URL; use
+// URLBuilder.CanonicalKey for base URLs that include a path prefix.
+func CanonicalKey(rawURL string) (string, error) {
+	p, err := ParseURL(rawURL)
+	if err != nil {
+		return "", err
+	}
+	return p.canonicalKey(), nil
+}
+
+// CanonicalKey is like the package-level CanonicalKey but parses rawURL with
+// URLBuilder.ParseURL.
+func (b *URLBuilder) CanonicalKey(rawURL string) (string, error) {
+	p, err := b.ParseURL(rawURL)
+	if err != nil {
+		return "", err
+	}
+	return p.canonicalKey(), nil
+}
+
+func (p *ParsedURL) canonicalKey() string {
+	c := *p
+	c.Options = CanonicalOptions(p.Options...)
+	c.BossToken = ""
+	key := c.String()
+	if _, rest, ok := strings.Cut(key, "//"); ok {
+		return rest
+	}
+	return key
+}
@@ -0,0 +1,67 @@
+package imageboss
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCanonicalOptions(t *testing.T) {
+	got := CanonicalOptions(FormatAuto(), Blur(4), Param(""), Quality(80), Param("sharpen", ""), Blur(2), Grayscale())
+	want := []string{"blur:2", "format:auto", "grayscale:true", "quality:80"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d options; want %v", len(got), want)
+	}
+	for i, o := range got {
+		if o.PathSegment() != want[i] {
+			t.Errorf("option %d = %s; want %s", i, o.PathSegment(), want[i])
+		}
+	}
+}
+
+func TestCreateURL_CanonicalOptions(t *testing.T) {
+	b := MustNewURLBuilder("demo", WithCanonicalOptions(true), WithDefaultOptions(FormatAuto()), WithSecret("secret"))
+	u1 := b.CreateURL("a.jpg", Width(300), Blur(4), Quality(70))
+	u2 := b.CreateURL("a.jpg", Width(300), Quality(90), Blur(4), Quality(70), Param(""))
+	if u1 != u2 {
+		t.Errorf("canonical URLs differ:\n%s\n%s", u1, u2)
+	}
+	want := "https://img.imageboss.me/demo/width/300/blur:4/format:auto/quality:70/a.jpg"
+	if got := u1[:len(want)]; got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+	if err := b.Verify(u1); err != nil {
+		t.Errorf("Verify = %v", err)
+	}
+
+	plain := MustNewURLBuilder("demo")
+	if got := plain.CreateURL("a.jpg", CDN(), Quality(70), Blur(4)); got != "https://img.imageboss.me/demo/cdn/quality:70/blur:4/a.jpg" {
+		t.Errorf("default builder reordered options: %s", got)
+	}
+}
+
+func TestCanonicalKey(t *testing.T) {
+	k1, err := CanonicalKey("https://img.imageboss.me/demo/width/300/format:auto/blur:4/a.jpg?bossToken=abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	k2, err := CanonicalKey("http://img.imageboss.me/demo/width/300/blur:1/blur:4/format:auto/a.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "img.imageboss.me/demo/width/300/blur:4/format:auto/a.jpg"
+	if k1 != want || k2 != want {
+		t.Errorf("keys = %q, %q; want %q", k1, k2, want)
+	}
+	if _, err := CanonicalKey("https://img.imageboss.me/demo"); !errors.Is(err, ErrInvalidURL) {
+		t.Errorf("CanonicalKey(invalid) = %v; want ErrInvalidURL", err)
+	}
+
+	b := MustNewURLBuilder("demo", WithBaseURL("https://cdn.example.com/images"))
+	got, err := b.CanonicalKey(b.CreateURL("a.jpg", CDN(), Quality(70), Blur(4)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "cdn.example.com/images/demo/cdn/blur:4/quality:70/a.jpg"; got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+}
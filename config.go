@@ -0,0 +1,464 @@
+package imageboss
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var optionKeyRegexp = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// Config is a JSON-loadable configuration for one or more ImageBoss sources.
+//
+//	{
+//	  "defaults": {"base_url": "https://img.imageboss.me", "options": ["format:auto"]},
+//	  "sources": {
+//	    "products": {"source": "acme-products", "srcset": {"max_width": 2000}},
+//	    "avatars": {"source": "acme-avatars", "secret_env": "AVATARS_SECRET"}
+//	  }
+//	}
+//
+// Defaults apply to every source; a source's own settings come after them, so
+// they override scalar settings and add to options.
+type Config struct {
+	Defaults SourceConfig            `json:"defaults"`
+	Sources  map[string]SourceConfig `json:"sources"`
+}
+
+// SourceConfig configures one URLBuilder.
+type SourceConfig struct {
+	// Source is the ImageBoss source name; it defaults to the key in Config.Sources.
+	Source  string `json:"source,omitempty"`
+	BaseURL string `json:"base_url,omitempty"`
+	HTTPS   *bool  `json:"https,omitempty"`
+	// At most one of Secret, SecretEnv and SecretFile may be set. SecretEnv
+	// and SecretFile are read at sign time (see SecretProvider).
+	Secret     string `json:"secret,omitempty"`
+	SecretEnv  string `json:"secret_env,omitempty"`
+	SecretFile string `json:"secret_file,omitempty"`
+	// Options are default options as path segments, e.g. "format:auto".
+	Options []string      `json:"options,omitempty"`
+	Srcset  *SrcsetConfig `json:"srcset,omitempty"`
+}
+
+// SrcsetConfig holds srcset defaults; zero values keep the package defaults.
+type SrcsetConfig struct {
+	MinWidth        int     `json:"min_width,omitempty"`
+	MaxWidth        int     `json:"max_width,omitempty"`
+	Tolerance       float64 `json:"tolerance,omitempty"`
+	VariableQuality *bool   `json:"variable_quality,omitempty"`
+}
+
+// LoadConfig decodes and validates a JSON Config. Unknown keys are rejected.
+// Validation failures are *ValidationError values whose Field is the offending
+// key, e.g. "sources.products.srcset.min_width".
+func LoadConfig(r io.Reader) (*Config, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var c Config
+	if err := dec.Decode(&c); err != nil {
+		return nil, invalid(ErrInvalidConfig, "config", nil, err.Error())
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// LoadConfigFile is LoadConfig for the file at path.
+func LoadConfigFile(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("imageboss: reading config: %w", err)
+	}
+	defer f.Close()
+	return LoadConfig(f)
+}
+
+// Validate checks every source, including that each can build a URLBuilder
+// and that referenced secrets can be read.
+func (c *Config) Validate() error {
+	if c.Defaults.Source != "" {
+		return invalid(ErrInvalidConfig, "defaults.source", c.Defaults.Source, "set the source per entry in sources")
+	}
+	if len(c.Sources) == 0 {
+		return invalid(ErrInvalidConfig, "sources", nil, "at least one source is required")
+	}
+	for _, name := range c.names() {
+		if _, err := c.Builder(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Builder returns a URLBuilder for the source registered under name.
+func (c *Config) Builder(name string) (*URLBuilder, error) {
+	sc, ok := c.Sources[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSource, name)
+	}
+	defaults, err := c.Defaults.builderOptions("defaults")
+	if err != nil {
+		return nil, err
+	}
+	key := "sources." + name
+	opts, err := sc.builderOptions(key)
+	if err != nil {
+		return nil, err
+	}
+	// Each level is valid on its own; the source's settings must also fit
+	// with the defaults they are applied on top of.
+	merged := append(c.Defaults.Srcset.srcsetOptions(), sc.Srcset.srcsetOptions()...)
+	if err := validateSrcsetDefaults(key+".srcset", merged); err != nil {
+		return nil, err
+	}
+	source := sc.Source
+	if source == "" {
+		source = name
+	}
+	b, err := NewURLBuilder(source, append(defaults, opts...)...)
+	if err != nil {
+		return nil, configError(key, err)
+	}
+	return b, nil
+}
+
+// Registry returns a Registry with a builder for every source, registered
+// under its key in Sources.
+func (c *Config) Registry() (*Registry, error) {
+	reg := NewRegistry()
+	for _, name := range c.names() {
+		b, err := c.Builder(name)
+		if err != nil {
+			return nil, err
+		}
+		if err := reg.Add(name, b); err != nil {
+			return nil, err
+		}
+	}
+	return reg, nil
+}
+
+func (c *Config) names() []string {
+	names := make([]string, 0, len(c.Sources))
+	for name := range c.Sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// builderOptions converts sc into BuilderOptions. key prefixes field names in errors.
+func (sc SourceConfig) builderOptions(key string) ([]BuilderOption, error) {
+	var opts []BuilderOption
+	if sc.BaseURL != "" {
+		if _, err := validateBaseURL(sc.BaseURL); err != nil {
+			return nil, configError(key, err)
+		}
+		opts = append(opts, WithBaseURL(sc.BaseURL))
+	}
+	if sc.HTTPS != nil {
+		opts = append(opts, WithHTTPS(*sc.HTTPS))
+	}
+
+	secrets := 0
+	for _, s := range []string{sc.Secret, sc.SecretEnv, sc.SecretFile} {
+		if s != "" {
+			secrets++
+		}
+	}
+	if secrets > 1 {
+		return nil, invalid(ErrInvalidConfig, key, nil, "set only one of secret, secret_env and secret_file")
+	}
+	switch {
+	case sc.Secret != "":
+		opts = append(opts, WithSecret(sc.Secret))
+	case sc.SecretEnv != "":
+		p := EnvSecret(sc.SecretEnv)
+		if _, err := p.Secret(); err != nil {
+			return nil, invalid(ErrInvalidConfig, key+".secret_env", sc.SecretEnv, err.Error())
+		}
+		opts = append(opts, WithSecretProvider(p))
+	case sc.SecretFile != "":
+		p := FileSecret(sc.SecretFile)
+		if _, err := p.Secret(); err != nil {
+			return nil, invalid(ErrInvalidConfig, key+".secret_file", sc.SecretFile, err.Error())
+		}
+		opts = append(opts, WithSecretProvider(p))
+	}
+
+	if len(sc.Options) > 0 {
+		options := make([]Option, 0, len(sc.Options))
+		for i, s := range sc.Options {
+			o, err := parseOptionSegment(s)
+			if err != nil {
+				return nil, withField(err, fmt.Sprintf("%s.options[%d]", key, i))
+			}
+			options = append(options, o)
+		}
+		opts = append(opts, WithDefaultOptions(options...))
+	}
+
+	if sc.Srcset != nil {
+		srcsetOpts := sc.Srcset.srcsetOptions()
+		if err := validateSrcsetDefaults(key+".srcset", srcsetOpts); err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithSrcsetDefaults(srcsetOpts...))
+	}
+	return opts, nil
+}
+
+// srcsetOptions converts sc into SrcsetOptions; a nil sc has none.
+func (sc *SrcsetConfig) srcsetOptions() []SrcsetOption {
+	if sc == nil {
+		return nil
+	}
+	var opts []SrcsetOption
+	if sc.MinWidth != 0 {
+		opts = append(opts, WithMinWidth(sc.MinWidth))
+	}
+	if sc.MaxWidth != 0 {
+		opts = append(opts, WithMaxWidth(sc.MaxWidth))
+	}
+	if sc.Tolerance != 0 {
+		opts = append(opts, WithTolerance(sc.Tolerance))
+	}
+	if sc.VariableQuality != nil {
+		opts = append(opts, WithVariableQuality(*sc.VariableQuality))
+	}
+	return opts
+}
+
+// validateSrcsetDefaults validates the range that opts give on top of the
+// package defaults. key prefixes field names in errors.
+func validateSrcsetDefaults(key string, opts []SrcsetOption) error {
+	o := newSrcsetOptions(nil, opts)
+	if _, err := validateRangeWithTolerance(o.MinWidth, o.MaxWidth, o.Tolerance); err != nil {
+		return configError(key, err)
+	}
+	if err := validateDimension("maxWidth", o.MaxWidth); err != nil {
+		return configError(key, err)
+	}
+	return nil
+}
+
+// parseOptionSegment parses a "key:value[,value...]" or bare "key" option.
+// Keys with a typed constructor (quality, blur, format, ...) are built with it
+// and validated like ValidateOptions does; other keys become a Param.
+func parseOptionSegment(s string) (Option, error) {
+	key, value, hasValue := strings.Cut(s, ":")
+	if !optionKeyRegexp.MatchString(key) {
+		return nil, invalid(ErrInvalidOption, "option", s, "want key:value")
+	}
+	var o Option
+	switch key {
+	case "quality", "blur", "sharpen":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, invalid(ErrInvalidOption, key, value, "not an integer")
+		}
+		switch key {
+		case "quality":
+			o = Quality(n)
+		case "blur":
+			o = Blur(n)
+		default:
+			o = Sharpen(n)
+		}
+	case "dpr":
+		dpr, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, invalid(ErrInvalidOption, key, value, "not a number")
+		}
+		o = DPR(dpr)
+	case "format":
+		o = Format(ImageFormat(value))
+	case "fill-color":
+		o = FillColor(value)
+	case "progressive", "animation", "grayscale":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return nil, invalid(ErrInvalidOption, key, value, "want true or false")
+		}
+		o = Param(key, value)
+	default:
+		switch {
+		case key == "download" && hasValue:
+			o = DownloadFilename(value)
+		case hasValue:
+			o = Param(key, strings.Split(value, ",")...)
+		default:
+			o = Param(key)
+		}
+	}
+	if err := ValidateOptions(o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// withField returns err with the Field of its *ValidationError replaced by
+// field, keeping its sentinel.
+func withField(err error, field string) error {
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		return err
+	}
+	e := *verr
+	e.Field = field
+	return &e
+}
+
+// configError prefixes the Field of a *ValidationError with the config key it
+// came from (e.g. "minWidth" under "sources.a.srcset" becomes
+// "sources.a.srcset.min_width"), keeping its sentinel. Other errors are wrapped
+// in a *ValidationError for ErrInvalidConfig.
+func configError(key string, err error) error {
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		e := *verr
+		e.Field = key + "." + snakeCase(e.Field)
+		return &e
+	}
+	return invalid(ErrInvalidConfig, key, nil, err.Error())
+}
+
+// snakeCase converts a field name such as "minWidth" or "baseURL" to the
+// matching JSON key ("min_width", "base_url").
+func snakeCase(s string) string {
+	var b strings.Builder
+	lower := false
+	for _, r := range s {
+		if r >= 'A' && r <= 'Z' {
+			if lower {
+				b.WriteByte('_')
+			}
+			r += 'a' - 'A'
+			lower = false
+		} else {
+			lower = true
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// NewURLBuilderFromEnv creates a URLBuilder from environment variables named
+// with prefix (default "IMAGEBOSS"):
+//
+//	<PREFIX>_SOURCE            source name (required)
+//	<PREFIX>_BASE_URL          base URL
+//	<PREFIX>_HTTPS             true or false
+//	<PREFIX>_SECRET            signing secret, read at sign time
+//	<PREFIX>_SECRET_FILE       file holding the signing secret, read at sign time
+//	<PREFIX>_OPTIONS           default options as path segments, e.g. "format:auto/quality:80"
+//	<PREFIX>_MIN_WIDTH, <PREFIX>_MAX_WIDTH, <PREFIX>_TOLERANCE, <PREFIX>_VARIABLE_QUALITY
+//	                           srcset defaults
+//
+// Validation failures are *ValidationError values whose Field is the variable name.
+func NewURLBuilderFromEnv(prefix string) (*URLBuilder, error) {
+	if prefix == "" {
+		prefix = "IMAGEBOSS"
+	}
+	prefix = strings.TrimSuffix(prefix, "_") + "_"
+	env := func(name string) string {
+		return strings.TrimSpace(os.Getenv(prefix + name))
+	}
+
+	sc := SourceConfig{
+		Source:     env("SOURCE"),
+		BaseURL:    env("BASE_URL"),
+		SecretFile: env("SECRET_FILE"),
+	}
+	if sc.Source == "" {
+		return nil, invalid(ErrInvalidSource, prefix+"SOURCE", "", "environment variable is not set")
+	}
+	if env("SECRET") != "" {
+		sc.SecretEnv = prefix + "SECRET"
+	}
+	if v := env("HTTPS"); v != "" {
+		https, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, invalid(ErrInvalidConfig, prefix+"HTTPS", v, "want true or false")
+		}
+		sc.HTTPS = &https
+	}
+	if v := env("OPTIONS"); v != "" {
+		sc.Options = strings.Split(strings.Trim(v, "/"), "/")
+	}
+
+	var srcset SrcsetConfig
+	var err error
+	if srcset.MinWidth, err = envInt(prefix+"MIN_WIDTH", env("MIN_WIDTH")); err != nil {
+		return nil, err
+	}
+	if srcset.MaxWidth, err = envInt(prefix+"MAX_WIDTH", env("MAX_WIDTH")); err != nil {
+		return nil, err
+	}
+	if v := env("TOLERANCE"); v != "" {
+		if srcset.Tolerance, err = strconv.ParseFloat(v, 64); err != nil {
+			return nil, invalid(ErrInvalidTolerance, prefix+"TOLERANCE", v, "not a number")
+		}
+	}
+	if v := env("VARIABLE_QUALITY"); v != "" {
+		vq, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, invalid(ErrInvalidConfig, prefix+"VARIABLE_QUALITY", v, "want true or false")
+		}
+		srcset.VariableQuality = &vq
+	}
+	if srcset != (SrcsetConfig{}) {
+		sc.Srcset = &srcset
+	}
+
+	opts, err := sc.builderOptions(strings.TrimSuffix(prefix, "_"))
+	if err != nil {
+		return nil, envError(prefix, err)
+	}
+	b, err := NewURLBuilder(sc.Source, opts...)
+	if err != nil {
+		return nil, envError(prefix, configError(strings.TrimSuffix(prefix, "_"), err))
+	}
+	return b, nil
+}
+
+// envInt parses an optional integer environment variable.
+func envInt(name, v string) (int, error) {
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, invalid(ErrInvalidConfig, name, v, "not an integer")
+	}
+	return n, nil
+}
+
+// envError renames the config key in a *ValidationError from SourceConfig
+// (e.g. "IMAGEBOSS.srcset.min_width") to its environment variable
+// ("IMAGEBOSS_MIN_WIDTH").
+func envError(prefix string, err error) error {
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		return err
+	}
+	field, ok := strings.CutPrefix(verr.Field, strings.TrimSuffix(prefix, "_")+".")
+	if !ok {
+		return err
+	}
+	field = strings.TrimPrefix(field, "srcset.")
+	field, _, _ = strings.Cut(field, "[")
+	if field == "secret_env" {
+		field = "secret"
+	}
+	e := *verr
+	e.Field = prefix + strings.ToUpper(field)
+	return &e
+}
@@ -0,0 +1,218 @@
+package imageboss
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testConfig = `{
+  "defaults": {"base_url": "https://img.example.com", "options": ["format:auto"]},
+  "sources": {
+    "products": {"source": "acme-products", "srcset": {"min_width": 100, "max_width": 400}},
+    "avatars": {"source": "acme-avatars", "secret": "avatarsecret", "https": false}
+  }
+}`
+
+func TestLoadConfig(t *testing.T) {
+	cfg, err := LoadConfig(strings.NewReader(testConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg, err := cfg.Registry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := reg.Source("products").CreateURL("shoe.jpg", Width(300))
+	if want := "https://img.example.com/acme-products/width/300/format:auto/shoe.jpg"; got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+	srcset := reg.Source("products").CreateSrcset("shoe.jpg", CDN(), nil)
+	if !strings.HasPrefix(srcset, "https://img.example.com/acme-products/width/100/format:auto/shoe.jpg 100w") ||
+		!strings.Contains(srcset, "/width/400/") || strings.Contains(srcset, "/width/8192/") {
+		t.Errorf("srcset does not use configured widths:\n%s", srcset)
+	}
+	avatar := reg.Source("avatars").CreateURL("me.jpg", Width(64))
+	if !strings.HasPrefix(avatar, "http://img.example.com/acme-avatars/") || !strings.Contains(avatar, "bossToken=") {
+		t.Errorf("avatars URL = %s; want http and signed", avatar)
+	}
+}
+
+func TestLoadConfig_SourceDefaultsToKey(t *testing.T) {
+	cfg, err := LoadConfig(strings.NewReader(`{"sources": {"demo": {}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := cfg.Builder("demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := b.Source(); got != "demo" {
+		t.Errorf("Source() = %q; want demo", got)
+	}
+	if _, err := cfg.Builder("missing"); !errors.Is(err, ErrUnknownSource) {
+		t.Errorf("Builder(missing) = %v; want ErrUnknownSource", err)
+	}
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		json  string
+		err   error
+		field string
+	}{
+		{"unknown key", `{"sources": {"a": {"base": "x"}}}`, ErrInvalidConfig, "config"},
+		{"no sources", `{}`, ErrInvalidConfig, "sources"},
+		{"bad source", `{"sources": {"a": {"source": "bad source"}}}`, ErrInvalidSource, "sources.a.source"},
+		{"bad base url", `{"sources": {"a": {"base_url": "ftp://x"}}}`, ErrInvalidBaseURL, "sources.a.base_url"},
+		{"bad default base url", `{"defaults": {"base_url": "nohost"}, "sources": {"a": {}}}`, ErrInvalidBaseURL, "defaults.base_url"},
+		{"bad option", `{"sources": {"a": {"options": ["format:auto", "Bad"]}}}`, ErrInvalidOption, "sources.a.options[1]"},
+		{"quality out of range", `{"sources": {"a": {"options": ["quality:500"]}}}`, ErrInvalidOption, "sources.a.options[0]"},
+		{"quality not a number", `{"sources": {"a": {"options": ["quality:high"]}}}`, ErrInvalidOption, "sources.a.options[0]"},
+		{"unknown format", `{"defaults": {"options": ["format:gif"]}, "sources": {"a": {}}}`, ErrInvalidOption, "defaults.options[0]"},
+		{"bad fill color", `{"sources": {"a": {"options": ["blur:2", "fill-color:red"]}}}`, ErrInvalidOption, "sources.a.options[1]"},
+		{"bad min width", `{"sources": {"a": {"srcset": {"min_width": -1}}}}`, ErrInvalidWidthRange, "sources.a.srcset.min_width"},
+		{"inverted range", `{"sources": {"a": {"srcset": {"min_width": 500, "max_width": 100}}}}`, ErrInvalidWidthRange, "sources.a.srcset.max_width"},
+		{"range from defaults and source", `{"defaults": {"srcset": {"max_width": 500}}, "sources": {"a": {"srcset": {"min_width": 1000}}}}`, ErrInvalidWidthRange, "sources.a.srcset.max_width"},
+		{"max width too large", `{"sources": {"a": {"srcset": {"max_width": 9000}}}}`, ErrDimensionTooLarge, "sources.a.srcset.max_width"},
+		{"two secrets", `{"sources": {"a": {"secret": "x", "secret_file": "y"}}}`, ErrInvalidConfig, "sources.a"},
+		{"missing secret env", `{"sources": {"a": {"secret_env": "IMAGEBOSS_TEST_UNSET"}}}`, ErrInvalidConfig, "sources.a.secret_env"},
+		{"first source in name order", `{"sources": {"b": {"source": "!"}, "a": {"source": "?"}}}`, ErrInvalidSource, "sources.a.source"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(strings.NewReader(tt.json))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v; want %v", err, tt.err)
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) || verr.Field != tt.field {
+				t.Errorf("err = %v; want Field %q", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFile_SecretFile(t *testing.T) {
+	dir := t.TempDir()
+	secretPath := filepath.Join(dir, "secret")
+	if err := os.WriteFile(secretPath, []byte("filesecret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(dir, "imageboss.json")
+	data := `{"sources": {"demo": {"secret_file": "` + filepath.ToSlash(secretPath) + `"}}}`
+	if err := os.WriteFile(configPath, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfigFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := cfg.Builder("demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := MustNewURLBuilder("demo", WithSecret("filesecret")).CreateURL("a.jpg", Width(10))
+	if got := b.CreateURL("a.jpg", Width(10)); got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+	if _, err := LoadConfigFile(filepath.Join(dir, "missing.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("LoadConfigFile(missing) = %v; want os.ErrNotExist", err)
+	}
+}
+
+func TestNewURLBuilderFromEnv(t *testing.T) {
+	t.Setenv("IMAGEBOSS_SOURCE", "demo")
+	t.Setenv("IMAGEBOSS_BASE_URL", "https://img.example.com")
+	t.Setenv("IMAGEBOSS_HTTPS", "false")
+	t.Setenv("IMAGEBOSS_SECRET", "envsecret")
+	t.Setenv("IMAGEBOSS_OPTIONS", "format:auto/quality:80")
+	t.Setenv("IMAGEBOSS_MIN_WIDTH", "200")
+	t.Setenv("IMAGEBOSS_MAX_WIDTH", "300")
+	b, err := NewURLBuilderFromEnv("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := MustNewURLBuilder("demo", WithBaseURL("http://img.example.com"), WithSecret("envsecret")).
+		CreateURL("a.jpg", Width(10), FormatAuto(), Quality(80))
+	if got := b.CreateURL("a.jpg", Width(10)); got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+	if got := b.CreateSrcset("a.jpg", CDN(), nil); !strings.Contains(got, " 200w") || !strings.HasSuffix(got, " 300w") {
+		t.Errorf("srcset does not use env widths:\n%s", got)
+	}
+
+	// The secret is read at sign time.
+	t.Setenv("IMAGEBOSS_SECRET", "rotated")
+	rotated := MustNewURLBuilder("demo", WithBaseURL("http://img.example.com"), WithSecret("rotated")).
+		CreateURL("a.jpg", Width(10), FormatAuto(), Quality(80))
+	if got := b.CreateURL("a.jpg", Width(10)); got != rotated {
+		t.Errorf("after rotation:\ngot:  %s\nwant: %s", got, rotated)
+	}
+}
+
+func TestNewURLBuilderFromEnv_Prefix(t *testing.T) {
+	t.Setenv("CDN_SOURCE", "prefixed")
+	b, err := NewURLBuilderFromEnv("CDN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := b.Source(); got != "prefixed" {
+		t.Errorf("Source() = %q; want prefixed", got)
+	}
+}
+
+func TestNewURLBuilderFromEnv_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   map[string]string
+		err   error
+		field string
+	}{
+		{"missing source", nil, ErrInvalidSource, "IMAGEBOSS_SOURCE"},
+		{"bad source", map[string]string{"SOURCE": "a b"}, ErrInvalidSource, "IMAGEBOSS_SOURCE"},
+		{"bad https", map[string]string{"HTTPS": "maybe"}, ErrInvalidConfig, "IMAGEBOSS_HTTPS"},
+		{"bad base url", map[string]string{"BASE_URL": "nohost"}, ErrInvalidBaseURL, "IMAGEBOSS_BASE_URL"},
+		{"bad option", map[string]string{"OPTIONS": "format:auto/Bad"}, ErrInvalidOption, "IMAGEBOSS_OPTIONS"},
+		{"option out of range", map[string]string{"OPTIONS": "blur:99"}, ErrInvalidOption, "IMAGEBOSS_OPTIONS"},
+		{"bad min width", map[string]string{"MIN_WIDTH": "wide"}, ErrInvalidConfig, "IMAGEBOSS_MIN_WIDTH"},
+		{"inverted range", map[string]string{"MIN_WIDTH": "500", "MAX_WIDTH": "100"}, ErrInvalidWidthRange, "IMAGEBOSS_MAX_WIDTH"},
+		{"bad tolerance", map[string]string{"TOLERANCE": "0.001"}, ErrInvalidTolerance, "IMAGEBOSS_TOLERANCE"},
+		{"missing secret file", map[string]string{"SECRET_FILE": "/nonexistent/secret"}, ErrInvalidConfig, "IMAGEBOSS_SECRET_FILE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("IMAGEBOSS_SOURCE", "demo")
+			if tt.env == nil {
+				os.Unsetenv("IMAGEBOSS_SOURCE")
+			}
+			for k, v := range tt.env {
+				t.Setenv("IMAGEBOSS_"+k, v)
+			}
+			_, err := NewURLBuilderFromEnv("IMAGEBOSS")
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v; want %v", err, tt.err)
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) || verr.Field != tt.field {
+				t.Errorf("err = %v; want Field %q", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestParseOptionSegment(t *testing.T) {
+	for _, s := range []string{"quality:80", "blur:4", "dpr:1.5", "format:webp", "fill-color:ff0000", "download", "download:a.jpg", "progressive:true", "trim:10,20"} {
+		o, err := parseOptionSegment(s)
+		if err != nil {
+			t.Errorf("parseOptionSegment(%q) = %v", s, err)
+			continue
+		}
+		if got := o.PathSegment(); got != s {
+			t.Errorf("parseOptionSegment(%q).PathSegment() = %q", s, got)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package imageboss
+
+import (
+	"strconv"
+	"strings"
+)
+
+// cssEscaper escapes a CSS string. "<" is escaped so the result is safe in a
+// <style> element.
+var cssEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\a `, "<", `\3c `)
+
+// ImageSet returns a CSS image-set() value for the image, with an entry per
+// density as in a DPR-based CreateSrcset (see WithDensities):
+//
+//	image-set(url("…/width/700/a.jpg") 1x, url("…/width/700/dpr:2/a.jpg") 2x)
+//
+// With formats, each density has an entry per format, in order, using an
+// explicit format option and a type() hint so browsers skip formats they do
+// not support; end formats with a widely supported one such as JPEG:
+//
+//	b.ImageSet("a.jpg", imageboss.Width(700), nil, []imageboss.ImageFormat{imageboss.AVIF, imageboss.JPEG})
+//
+// op should fix a dimension; use BackgroundCSS for fluid images. Unknown
+// formats are left out; use BuildImageSet to get an error instead.
+func (b *URLBuilder) ImageSet(path string, op Operation, options []Option, formats []ImageFormat, srcsetOpts ...SrcsetOption) string {
+	b = b.snapshot()
+	set, _, _ := b.imageSet(path, op, options, formats, newSrcsetOptions(b.config().srcsetDefaults, srcsetOpts))
+	return set
+}
+
+// BuildImageSet is like ImageSet but validates its arguments first, as
+// BuildSrcset does, and returns a *ValidationError wrapping ErrInvalidOption
+// for an Auto or unknown format or ErrInvalidOperation for an op that fixes
+// neither dimension.
+func (b *URLBuilder) BuildImageSet(path string, op Operation, options []Option, formats []ImageFormat, srcsetOpts ...SrcsetOption) (string, error) {
+	if err := validateFormats(formats); err != nil {
+		return "", err
+	}
+	b = b.snapshot()
+	opts := newSrcsetOptions(b.config().srcsetDefaults, srcsetOpts)
+	if err := validateSrcsetArgs(path, op, options, opts); err != nil {
+		return "", err
+	}
+	if err := validateFixedSize(op); err != nil {
+		return "", err
+	}
+	set, _, err := b.imageSet(path, op, options, formats, opts)
+	return set, err
+}
+
+// imageSet builds the image-set() value for ImageSet, and returns the srcset
+// without a format option if it built one (that is, if formats is empty). On
+// a signing error it returns the value with unsigned URLs and the first error.
+func (b *URLBuilder) imageSet(path string, op Operation, options []Option, formats []ImageFormat, opts SrcsetOptions) (string, Srcset, error) {
+	var types []string
+	var sets []Srcset
+	var firstErr error
+	add := func(typ string, options []Option) {
+		set, err := b.buildSrcsetDPR(path, op, options, opts)
+		if firstErr == nil {
+			firstErr = err
+		}
+		types = append(types, typ)
+		sets = append(sets, set)
+	}
+	for _, f := range formats {
+		if mimeTypes[f] != "" {
+			add(` type("`+mimeTypes[f]+`")`, withFormat(options, f))
+		}
+	}
+	var plain Srcset
+	if len(sets) == 0 {
+		add("", options)
+		plain = sets[0]
+	}
+	// Every set has the same densities, as only the format differs.
+	var entries []string
+	for i := range sets[0] {
+		for j, set := range sets {
+			entries = append(entries, cssURL(set[i].URL)+types[j]+" "+set[i].Descriptor())
+		}
+	}
+	return "image-set(" + strings.Join(entries, ", ") + ")", plain, firstErr
+}
+
+// BackgroundCSS returns CSS rules setting the background image of the
+// elements matching selector, mobile first: a rule for the smallest srcset
+// width and an @media (min-width: …) rule for each larger one, so every
+// viewport gets the smallest image at least as wide as itself. Widths come
+// from TargetWidths and the srcset options, and each rule's image from
+// op.ResizeToWidth, so cover crops keep their aspect ratio:
+//
+//	.hero {
+//	  background-image: url("…/width/320/hero.jpg");
+//	  background-image: image-set(url("…/width/320/hero.jpg") 1x, url("…/width/320/dpr:2/hero.jpg") 2x);
+//	}
+//	@media (min-width: 321px) {
+//	  .hero {
+//	    …
+//	  }
+//	}
+//
+// The url() declaration, the 1x image, is for browsers without image-set();
+// formats and the density options apply to image-set() as in ImageSet. The
+// rules assume the element spans the viewport. Invalid selectors and formats
+// are not checked; use BuildBackgroundCSS to get an error for them instead.
+func (b *URLBuilder) BackgroundCSS(selector, path string, op Operation, options []Option, formats []ImageFormat, srcsetOpts ...SrcsetOption) string {
+	b = b.snapshot()
+	css, _ := b.backgroundCSS(selector, path, op, options, formats, newSrcsetOptions(b.config().srcsetDefaults, srcsetOpts))
+	return css
+}
+
+// backgroundCSS builds the rules for BackgroundCSS. On a signing error it
+// returns the rules with unsigned URLs and the first error.
+func (b *URLBuilder) backgroundCSS(selector, path string, op Operation, options []Option, formats []ImageFormat, opts SrcsetOptions) (string, error) {
+	widths := TargetWidths(opts.MinWidth, opts.MaxWidth, opts.Tolerance)
+	var sb strings.Builder
+	var firstErr error
+	for i, w := range widths {
+		indent := ""
+		if i > 0 {
+			sb.WriteString("@media (min-width: " + strconv.Itoa(widths[i-1]+1) + "px) {\n")
+			indent = "  "
+		}
+		rop := op.ResizeToWidth(w)
+		set, plain, err := b.imageSet(path, rop, options, formats, opts)
+		if firstErr == nil {
+			firstErr = err
+		}
+		fallback, err := b.fallbackURL(path, rop, options, plain, opts)
+		if firstErr == nil {
+			firstErr = err
+		}
+		sb.WriteString(indent + selector + " {\n")
+		sb.WriteString(indent + "  background-image: " + cssURL(fallback) + ";\n")
+		sb.WriteString(indent + "  background-image: " + set + ";\n")
+		sb.WriteString(indent + "}\n")
+		if i > 0 {
+			sb.WriteString("}\n")
+		}
+	}
+	return sb.String(), firstErr
+}
+
+// fallbackURL returns the URL of the 1x entry of a DPR-based srcset, so
+// browsers that also support image-set() can reuse it. plain is that srcset
+// if imageSet built it; otherwise only the 1x entry is built.
+func (b *URLBuilder) fallbackURL(path string, op Operation, options []Option, plain Srcset, opts SrcsetOptions) (string, error) {
+	var err error
+	if plain == nil {
+		for _, d := range opts.Densities {
+			if d == 1 {
+				opts.Densities = []float64{1}
+				plain, err = b.buildSrcsetDPR(path, op, options, opts)
+				break
+			}
+		}
+	}
+	for _, e := range plain {
+		if e.Density == 1 {
+			return e.URL, err
+		}
+	}
+	return b.config().createURL(path, op, options)
+}
+
+// BuildBackgroundCSS is like BackgroundCSS but validates its arguments first,
+// as BuildImageSet does, and returns a *ValidationError wrapping
+// ErrInvalidSelector for an empty selector or one that could end the rule, or
+// ErrInvalidOperation if op.ResizeToWidth does not fix a dimension.
+func (b *URLBuilder) BuildBackgroundCSS(selector, path string, op Operation, options []Option, formats []ImageFormat, srcsetOpts ...SrcsetOption) (string, error) {
+	if strings.TrimSpace(selector) == "" || strings.ContainsAny(selector, "{};<") || strings.Contains(selector, "/*") {
+		return "", invalid(ErrInvalidSelector, "selector", selector, "")
+	}
+	if err := validateFormats(formats); err != nil {
+		return "", err
+	}
+	b = b.snapshot()
+	opts := newSrcsetOptions(b.config().srcsetDefaults, srcsetOpts)
+	if err := validateSrcsetArgs(path, op, options, opts); err != nil {
+		return "", err
+	}
+	if err := validateFixedSize(op.ResizeToWidth(opts.MinWidth)); err != nil {
+		return "", err
+	}
+	return b.backgroundCSS(selector, path, op, options, formats, opts)
+}
+
+// validateFixedSize checks that op fixes its width or height, so dpr options
+// can scale it.
+func validateFixedSize(op Operation) error {
+	if w, h := op.Size(); w == 0 && h == 0 {
+		return invalid(ErrInvalidOperation, "operation", op.PathSegment(), "must fix the width or height")
+	}
+	return nil
+}
+
+// validateFormats checks that each format has a MIME type, so it can be
+// rendered as a <source> type or a CSS type() hint.
+func validateFormats(formats []ImageFormat) error {
+	for _, f := range formats {
+		if mimeTypes[f] == "" {
+			return invalid(ErrInvalidOption, "format", string(f), "want an explicit format (avif, webp, jpg or png)")
+		}
+	}
+	return nil
+}
+
+// cssURL returns a CSS url() for u.
+func cssURL(u string) string {
+	return `url("` + cssEscaper.Replace(u) + `")`
+}
+
+// ImageSet returns a CSS image-set() value for the image (see
+// URLBuilder.ImageSet), or "" if Err is not nil. Invalid formats and srcset
+// options are recorded and reported by Err.
+func (r *ImageRequest) ImageSet(formats []ImageFormat, srcsetOpts ...SrcsetOption) string {
+	if r.Err() != nil {
+		return ""
+	}
+	set, err := r.b.BuildImageSet(r.path, r.op, r.options, formats, srcsetOpts...)
+	if err != nil {
+		r.errs = append(r.errs, err)
+		return ""
+	}
+	return set
+}
+
+// BackgroundCSS returns responsive background-image rules for the image (see
+// URLBuilder.BackgroundCSS), or "" if Err is not nil. Invalid arguments are
+// recorded and reported by Err.
+func (r *ImageRequest) BackgroundCSS(selector string, formats []ImageFormat, srcsetOpts ...SrcsetOption) string {
+	if r.Err() != nil {
+		return ""
+	}
+	css, err := r.b.BuildBackgroundCSS(selector, r.path, r.op, r.options, formats, srcsetOpts...)
+	if err != nil {
+		r.errs = append(r.errs, err)
+		return ""
+	}
+	return css
+}
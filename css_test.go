@@ -0,0 +1,134 @@
+package imageboss
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestImageSet(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	got := b.ImageSet("a.jpg", Width(700), nil, nil, WithDensities(1, 2), WithVariableQuality(false))
+	want := `image-set(url("https://img.imageboss.me/demo/width/700/a.jpg") 1x, url("https://img.imageboss.me/demo/width/700/dpr:2/a.jpg") 2x)`
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+
+	got = b.ImageSet("a.jpg", Width(700), []Option{FormatAuto()}, []ImageFormat{AVIF, JPEG}, WithDensities(1, 2))
+	want = `image-set(` +
+		`url("https://img.imageboss.me/demo/width/700/format:avif/quality:75/a.jpg") type("image/avif") 1x, ` +
+		`url("https://img.imageboss.me/demo/width/700/format:jpg/quality:75/a.jpg") type("image/jpeg") 1x, ` +
+		`url("https://img.imageboss.me/demo/width/700/format:avif/dpr:2/quality:50/a.jpg") type("image/avif") 2x, ` +
+		`url("https://img.imageboss.me/demo/width/700/format:jpg/dpr:2/quality:50/a.jpg") type("image/jpeg") 2x)`
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestCSSURL(t *testing.T) {
+	if got, want := cssURL(`a"b\c<d`), `url("a\"b\\c\3c d")`; got != want {
+		t.Errorf("cssURL = %s; want %s", got, want)
+	}
+}
+
+func TestBackgroundCSS(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	got := b.BackgroundCSS(".hero", "hero.jpg", CoverAspect(800, "16:9"), nil, nil,
+		WithMinWidth(320), WithMaxWidth(640), WithTolerance(0.2), WithDensities(1), WithVariableQuality(false))
+	want := `.hero {
+  background-image: url("https://img.imageboss.me/demo/cover/320x180/hero.jpg");
+  background-image: image-set(url("https://img.imageboss.me/demo/cover/320x180/hero.jpg") 1x);
+}
+@media (min-width: 321px) {
+  .hero {
+    background-image: url("https://img.imageboss.me/demo/cover/448x252/hero.jpg");
+    background-image: image-set(url("https://img.imageboss.me/demo/cover/448x252/hero.jpg") 1x);
+  }
+}
+@media (min-width: 449px) {
+  .hero {
+    background-image: url("https://img.imageboss.me/demo/cover/627x353/hero.jpg");
+    background-image: image-set(url("https://img.imageboss.me/demo/cover/627x353/hero.jpg") 1x);
+  }
+}
+@media (min-width: 628px) {
+  .hero {
+    background-image: url("https://img.imageboss.me/demo/cover/640x360/hero.jpg");
+    background-image: image-set(url("https://img.imageboss.me/demo/cover/640x360/hero.jpg") 1x);
+  }
+}
+`
+	if got != want {
+		t.Errorf("\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestBackgroundCSS_FallbackMatches1x(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	css := b.BackgroundCSS("body", "a.jpg", CDN(), nil, nil, WithMinWidth(400), WithMaxWidth(400))
+	fallback := `url("https://img.imageboss.me/demo/width/400/quality:75/a.jpg");`
+	if !strings.Contains(css, "background-image: "+fallback) || !strings.Contains(css, `image-set(url("https://img.imageboss.me/demo/width/400/quality:75/a.jpg") 1x, `) {
+		t.Errorf("url() fallback is not the 1x image:\n%s", css)
+	}
+	if strings.Contains(css, "@media") {
+		t.Errorf("a single width needs no @media rule:\n%s", css)
+	}
+}
+
+func TestBuildBackgroundCSS_Invalid(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	tests := []struct {
+		name     string
+		selector string
+		op       Operation
+		formats  []ImageFormat
+		opts     []SrcsetOption
+		want     error
+	}{
+		{"empty selector", " ", CDN(), nil, nil, ErrInvalidSelector},
+		{"selector ends rule", ".a} body {", CDN(), nil, nil, ErrInvalidSelector},
+		{"auto format", ".a", CDN(), []ImageFormat{Auto}, nil, ErrInvalidOption},
+		{"bad range", ".a", CDN(), nil, []SrcsetOption{WithMinWidth(900), WithMaxWidth(100)}, ErrInvalidWidthRange},
+		{"bad density", ".a", CDN(), nil, []SrcsetOption{WithDensities(9)}, ErrInvalidDensity},
+		{"bad operation", ".a", CoverAspect(800, "x"), nil, nil, ErrInvalidAspectRatio},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := b.BuildBackgroundCSS(tt.selector, "a.jpg", tt.op, nil, tt.formats, tt.opts...); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v; want %v", err, tt.want)
+			}
+		})
+	}
+	if _, err := b.BuildImageSet("a.jpg", Width(100), nil, []ImageFormat{"gif"}); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("BuildImageSet err = %v; want ErrInvalidOption", err)
+	}
+	if _, err := b.BuildImageSet("a.jpg", CDN(), nil, nil); !errors.Is(err, ErrInvalidOperation) {
+		t.Errorf("BuildImageSet(CDN) err = %v; want ErrInvalidOperation", err)
+	}
+	if _, err := b.BuildBackgroundCSS(".a", "a.jpg", fluidOperation{}, nil, nil); !errors.Is(err, ErrInvalidOperation) {
+		t.Errorf("BuildBackgroundCSS(fluid resize) err = %v; want ErrInvalidOperation", err)
+	}
+}
+
+func TestImageRequest_CSS(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	if got := b.Image("a.jpg").Width(300).ImageSet(nil, WithDensities(1)); !strings.HasPrefix(got, `image-set(url("https://img.imageboss.me/demo/width/300/`) {
+		t.Errorf("ImageSet = %q", got)
+	}
+	r := b.Image("a.jpg")
+	if got := r.BackgroundCSS("{", nil); got != "" || !errors.Is(r.Err(), ErrInvalidSelector) {
+		t.Errorf("BackgroundCSS = %q, Err() = %v", got, r.Err())
+	}
+	if got := b.Image("a.jpg").BackgroundCSS(".a", nil); !strings.HasPrefix(got, ".a {\n") {
+		t.Errorf("BackgroundCSS = %q", got)
+	}
+}
+
+// fluidOperation is a custom operation whose entries never fix a dimension.
+type fluidOperation struct{}
+
+func (fluidOperation) PathSegment() string         { return "fluid" }
+func (fluidOperation) Dimensions() string          { return "" }
+func (fluidOperation) Size() (int, int)            { return 0, 0 }
+func (fluidOperation) ResizeToWidth(int) Operation { return fluidOperation{} }
+func (fluidOperation) Validate() error             { return nil }
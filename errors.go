@@ -0,0 +1,107 @@
+package imageboss
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned by the package. Validation failures are reported as a
+// *ValidationError wrapping one of these; use errors.Is to test for them.
+var (
+	// ErrInvalidSource is returned for an empty or malformed source name.
+	ErrInvalidSource = errors.New("imageboss: invalid source")
+	// ErrInvalidBaseURL is returned for a base URL without a host or with a scheme other than http(s).
+	ErrInvalidBaseURL = errors.New("imageboss: invalid base URL")
+	// ErrInvalidURL is returned by ParseURL for a URL that is not an ImageBoss URL.
+	ErrInvalidURL = errors.New("imageboss: invalid ImageBoss URL")
+	// ErrInvalidOperation is returned for a nil Operation.
+	ErrInvalidOperation = errors.New("imageboss: invalid operation")
+	// ErrInvalidDimension is returned for a width or height that is not positive.
+	ErrInvalidDimension = errors.New("imageboss: width and height must be positive")
+	// ErrDimensionTooLarge is returned for a width or height above MaxDimension.
+	ErrDimensionTooLarge = errors.New("imageboss: dimension exceeds maximum")
+	// ErrInvalidAspectRatio is returned for a CoverAspect ratio that is not W:H
+	// with positive numbers.
+	ErrInvalidAspectRatio = errors.New("imageboss: invalid aspect ratio")
+	// ErrUnknownCoverMode is returned for a cover mode ImageBoss does not support.
+	ErrUnknownCoverMode = errors.New("imageboss: unknown cover mode")
+	// ErrEmptyPath is returned when the image path is empty.
+	ErrEmptyPath = errors.New("imageboss: image path cannot be empty")
+	// ErrInvalidOption is returned by ValidateOptions for an option value outside
+	// its documented range.
+	ErrInvalidOption = errors.New("imageboss: invalid option")
+	// ErrInvalidWidthRange is returned for a srcset width range with a minimum
+	// below 1 or a maximum below the minimum.
+	ErrInvalidWidthRange = errors.New("imageboss: invalid width range")
+	// ErrInvalidTolerance is returned for a srcset width tolerance below 0.01.
+	ErrInvalidTolerance = errors.New("imageboss: invalid width tolerance")
+	// ErrInvalidSizes is returned by Sizes.Widths for a non-positive length,
+	// DPR range or viewport range.
+	ErrInvalidSizes = errors.New("imageboss: invalid sizes")
+	// ErrInvalidDensity is returned for a srcset pixel density outside 1–5.
+	ErrInvalidDensity = errors.New("imageboss: invalid pixel density")
+	// ErrInvalidConfig is returned by LoadConfig and NewURLBuilderFromEnv for a
+	// malformed or inconsistent configuration value.
+	ErrInvalidConfig = errors.New("imageboss: invalid configuration")
+	// ErrInvalidAttribute is returned by BuildImg for an attribute name or
+	// value it cannot render.
+	ErrInvalidAttribute = errors.New("imageboss: invalid HTML attribute")
+	// ErrInvalidBreakpoint is returned by ArtDirection for breakpoints without
+	// exactly one fallback (no media condition) at the end.
+	ErrInvalidBreakpoint = errors.New("imageboss: invalid art direction breakpoint")
+	// ErrInvalidSrcset is returned by ParseSrcset for a malformed srcset.
+	ErrInvalidSrcset = errors.New("imageboss: invalid srcset")
+	// ErrInvalidSelector is returned by BuildBackgroundCSS for an empty CSS
+	// selector or one that could end the rule.
+	ErrInvalidSelector = errors.New("imageboss: invalid CSS selector")
+)
+
+// Errors returned by Registry.
+var (
+	// ErrUnknownSource is returned when no builder is registered for a name or URL.
+	ErrUnknownSource = errors.New("imageboss: source not registered")
+	// ErrDuplicateSource is returned when registering a name twice.
+	ErrDuplicateSource = errors.New("imageboss: source already registered")
+)
+
+// Errors returned when verifying signed URLs.
+var (
+	// ErrNoSecret is returned by URLBuilder.Verify when the builder has no secret or Signer.
+	ErrNoSecret = errors.New("imageboss: no secret configured")
+	// ErrMissingToken is returned when a URL has no bossToken query parameter.
+	ErrMissingToken = errors.New("imageboss: missing bossToken")
+	// ErrMalformedToken is returned when a bossToken is not a hex-encoded SHA-256 digest.
+	ErrMalformedToken = errors.New("imageboss: malformed bossToken")
+	// ErrBadSignature is returned when a bossToken does not match the URL path.
+	ErrBadSignature = errors.New("imageboss: bossToken does not match URL")
+)
+
+// ValidationError reports an invalid value: which field it was passed as, the
+// value itself and the sentinel error describing the problem.
+type ValidationError struct {
+	Field  string // e.g. "source", "width", "minWidth", "blur"
+	Value  any    // the offending value
+	Reason string // optional detail, e.g. "out of range 0–40"
+	Err    error  // sentinel such as ErrInvalidSource
+}
+
+func (e *ValidationError) Error() string {
+	msg := fmt.Sprintf("%v: %s %#v", e.Err, e.Field, e.Value)
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+// Unwrap returns the sentinel error, so errors.Is(err, ErrInvalidSource) works.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// invalid returns a *ValidationError; reason is formatted with args.
+func invalid(err error, field string, value any, reason string, args ...any) error {
+	if len(args) > 0 {
+		reason = fmt.Sprintf(reason, args...)
+	}
+	return &ValidationError{Field: field, Value: value, Reason: reason, Err: err}
+}
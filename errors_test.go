@@ -0,0 +1,58 @@
+package imageboss
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidationError(t *testing.T) {
+	_, err := NewURLBuilder("invalid source!")
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("NewURLBuilder err = %T; want *ValidationError", err)
+	}
+	if verr.Field != "source" || verr.Value != "invalid source!" {
+		t.Errorf("ValidationError = %+v; want field source, value %q", verr, "invalid source!")
+	}
+	if !errors.Is(err, ErrInvalidSource) {
+		t.Errorf("errors.Is(%v, ErrInvalidSource) = false", err)
+	}
+	if got, want := err.Error(), `imageboss: invalid source: source "invalid source!"`; got != want {
+		t.Errorf("Error() = %s; want %s", got, want)
+	}
+}
+
+func TestValidationError_Reason(t *testing.T) {
+	err := ValidateOptions(Blur(41))
+	if got, want := err.Error(), "imageboss: invalid option: blur 41: out of range 0–40"; got != want {
+		t.Errorf("Error() = %s; want %s", got, want)
+	}
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Field != "blur" || verr.Value != 41 {
+		t.Errorf("ValidationError = %+v; want field blur, value 41", verr)
+	}
+}
+
+func TestValidationError_Sentinels(t *testing.T) {
+	tests := []struct {
+		err  error
+		want error
+	}{
+		{func() error { _, err := NewURLBuilder(""); return err }(), ErrInvalidSource},
+		{func() error { _, err := NewURLBuilder("demo", WithBaseURL("ftp://x")); return err }(), ErrInvalidBaseURL},
+		{func() error { _, err := ComputeTargetWidths(0, 100, 0.08); return err }(), ErrInvalidWidthRange},
+		{func() error { _, err := ComputeTargetWidths(200, 100, 0.08); return err }(), ErrInvalidWidthRange},
+		{func() error { _, err := ComputeTargetWidths(100, 200, 0.001); return err }(), ErrInvalidTolerance},
+		{func() error { _, err := ParseURL("https://img.imageboss.me/demo/resize/1/a.jpg"); return err }(), ErrInvalidURL},
+		{Width(0).Validate(), ErrInvalidDimension},
+	}
+	for _, tt := range tests {
+		var verr *ValidationError
+		if !errors.As(tt.err, &verr) {
+			t.Errorf("%v: want *ValidationError, got %T", tt.err, tt.err)
+		}
+		if !errors.Is(tt.err, tt.want) {
+			t.Errorf("errors.Is(%v, %v) = false", tt.err, tt.want)
+		}
+	}
+}
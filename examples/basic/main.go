@@ -5,7 +5,6 @@ package main
 import (
 	"fmt"
 	"log"
-	"strings"
 
 	"github.com/imageboss/go"
 )
@@ -24,12 +23,12 @@ func main() {
 	fmt.Println("Cover center 320x320:", b.CreateURL(path, imageboss.CoverMode(320, 320, "center")))
 	fmt.Println("Width 700 + blur:", b.CreateURL(path, imageboss.Width(700), imageboss.Blur(4)))
 
-	srcset := b.CreateSrcset(path, imageboss.CDN(), nil, imageboss.WithMinWidth(100), imageboss.WithMaxWidth(400))
+	srcset := b.Srcset(path, imageboss.CDN(), nil, imageboss.WithMinWidth(100), imageboss.WithMaxWidth(400))
 	fmt.Println("Srcset (first 3):")
-	for i, line := range strings.Split(srcset, ",\n") {
-		if i >= 3 {
-			break
-		}
-		fmt.Println(" ", strings.TrimSpace(line))
+	for _, e := range srcset[:3] {
+		fmt.Println(" ", e)
+	}
+	if e, ok := srcset.ClosestTo(300); ok {
+		fmt.Println("Closest to 300px:", e.URL)
 	}
 }
@@ -4,9 +4,9 @@ package main
 
 import (
 	"fmt"
+	"html/template"
 	"log"
 	"net/http"
-	"html/template"
 
 	"github.com/imageboss/go"
 )
@@ -81,6 +81,9 @@ func main() {
 	}
 
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		// The shared builder is never modified; a request for another source
+		// gets its own builder.
+		b, source := b, source
 		if s := r.URL.Query().Get("source"); s != "" {
 			var err error
 			b, err = imageboss.NewURLBuilder(s)
@@ -92,12 +95,12 @@ func main() {
 		}
 
 		data := map[string]string{
-			"Source":     source,
-			"URLCDN":     b.CreateURL(imagePath, imageboss.CDN()),
-			"URLWidth":   b.CreateURL(imagePath, imageboss.Width(400)),
-			"URLCover":   b.CreateURL(imagePath, imageboss.Cover(300, 300)),
-			"URLBlur":    b.CreateURL(imagePath, imageboss.Width(400), imageboss.Blur(4)),
-			"Srcset":     b.CreateSrcset(imagePath, imageboss.CDN(), nil, imageboss.WithMinWidth(100), imageboss.WithMaxWidth(400)),
+			"Source":   source,
+			"URLCDN":   b.CreateURL(imagePath, imageboss.CDN()),
+			"URLWidth": b.CreateURL(imagePath, imageboss.Width(400)),
+			"URLCover": b.CreateURL(imagePath, imageboss.Cover(300, 300)),
+			"URLBlur":  b.CreateURL(imagePath, imageboss.Width(400), imageboss.Blur(4)),
+			"Srcset":   b.CreateSrcset(imagePath, imageboss.CDN(), nil, imageboss.WithMinWidth(100), imageboss.WithMaxWidth(400)),
 		}
 
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -0,0 +1,164 @@
+package imageboss
+
+import "errors"
+
+// ImageRequest is a chainable description of one image: its path, operation and
+// options. Create one with URLBuilder.Image, chain setters, then call URL or
+// Srcset:
+//
+//	url := b.Image("examples/02.jpg").Width(700).Blur(4).Format(imageboss.Auto).URL()
+//
+// Invalid values (e.g. Width(0) or Blur(50)) are recorded rather than
+// rendered; URL and Srcset then return "" and Err reports every problem.
+// An ImageRequest is not safe for concurrent use; use Clone to branch.
+type ImageRequest struct {
+	b       *URLBuilder
+	path    string
+	op      Operation
+	options []Option
+	errs    []error
+}
+
+// Image starts an ImageRequest for path with the CDN operation.
+func (b *URLBuilder) Image(path string) *ImageRequest {
+	r := &ImageRequest{b: b, path: path, op: CDN()}
+	if err := validatePath(path); err != nil {
+		r.errs = append(r.errs, err)
+	}
+	return r
+}
+
+// Clone returns a copy of r that can be modified independently.
+func (r *ImageRequest) Clone() *ImageRequest {
+	c := *r
+	c.options = append([]Option(nil), r.options...)
+	c.errs = append([]error(nil), r.errs...)
+	return &c
+}
+
+// Operation sets the operation, replacing any previous one.
+func (r *ImageRequest) Operation(op Operation) *ImageRequest {
+	if err := validateOperation(op); err != nil {
+		r.errs = append(r.errs, err)
+	}
+	r.op = op
+	return r
+}
+
+// Width sets a width operation. See Width.
+func (r *ImageRequest) Width(w int) *ImageRequest {
+	return r.Operation(Width(w))
+}
+
+// Height sets a height operation. See Height.
+func (r *ImageRequest) Height(h int) *ImageRequest {
+	return r.Operation(Height(h))
+}
+
+// Cover sets a cover operation. See Cover.
+func (r *ImageRequest) Cover(w, h int) *ImageRequest {
+	return r.Operation(Cover(w, h))
+}
+
+// CoverMode sets a cover operation with a mode. See CoverMode.
+func (r *ImageRequest) CoverMode(w, h int, mode string) *ImageRequest {
+	return r.Operation(CoverMode(w, h, mode))
+}
+
+// Option adds options in order.
+func (r *ImageRequest) Option(options ...Option) *ImageRequest {
+	for _, o := range options {
+		if err := ValidateOptions(o); err != nil {
+			r.errs = append(r.errs, err)
+		}
+	}
+	r.options = append(r.options, options...)
+	return r
+}
+
+// If calls fn with r when cond is true, for options that only apply sometimes:
+//
+//	b.Image(p).Width(700).If(blurred, func(r *imageboss.ImageRequest) { r.Blur(4) })
+func (r *ImageRequest) If(cond bool, fn func(*ImageRequest)) *ImageRequest {
+	if cond {
+		fn(r)
+	}
+	return r
+}
+
+// NoDefaults leaves out the builder's default options, or only those with the
+// given keys. See NoDefaults.
+func (r *ImageRequest) NoDefaults(keys ...string) *ImageRequest {
+	return r.Option(NoDefaults(keys...))
+}
+
+// Blur adds a blur option. See Blur.
+func (r *ImageRequest) Blur(n int) *ImageRequest {
+	return r.Option(Blur(n))
+}
+
+// Quality adds a quality option. See Quality.
+func (r *ImageRequest) Quality(q int) *ImageRequest {
+	return r.Option(Quality(q))
+}
+
+// Format adds a format option. See Format.
+func (r *ImageRequest) Format(f ImageFormat) *ImageRequest {
+	return r.Option(Format(f))
+}
+
+// Grayscale adds the grayscale option.
+func (r *ImageRequest) Grayscale() *ImageRequest {
+	return r.Option(Grayscale())
+}
+
+// FillColor adds a fill-color option. See FillColor.
+func (r *ImageRequest) FillColor(color string) *ImageRequest {
+	return r.Option(FillColor(color))
+}
+
+// DPR adds a dpr option. See DPR.
+func (r *ImageRequest) DPR(dpr float64) *ImageRequest {
+	return r.Option(DPR(dpr))
+}
+
+// Download adds the download option. See Download and DownloadFilename.
+func (r *ImageRequest) Download(filename ...string) *ImageRequest {
+	if len(filename) > 0 {
+		return r.Option(DownloadFilename(filename[0]))
+	}
+	return r.Option(Download())
+}
+
+// Err returns all validation errors recorded so far, joined, or nil.
+func (r *ImageRequest) Err() error {
+	return errors.Join(r.errs...)
+}
+
+// URL returns the image URL, or "" if Err is not nil. A signing error is
+// recorded and reported by Err.
+func (r *ImageRequest) URL() string {
+	if r.Err() != nil {
+		return ""
+	}
+	url, err := r.b.BuildURL(r.path, r.op, r.options...)
+	if err != nil {
+		r.errs = append(r.errs, err)
+		return ""
+	}
+	return url
+}
+
+// Srcset returns a srcset for the image (see URLBuilder.CreateSrcset), or "" if
+// Err is not nil. Invalid srcset options are recorded and reported by Err.
+func (r *ImageRequest) Srcset(srcsetOpts ...SrcsetOption) string {
+	if r.Err() != nil {
+		return ""
+	}
+	srcset, err := r.b.BuildSrcset(r.path, r.op, r.options, srcsetOpts...)
+	if err != nil {
+		r.errs = append(r.errs, err)
+		return ""
+	}
+	return srcset
+}
@@ -0,0 +1,84 @@
+package imageboss
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestImageRequest_URL(t *testing.T) {
+	b := MustNewURLBuilder("mywebsite-images")
+	got := b.Image("examples/02.jpg").Width(700).Blur(4).Format(Auto).URL()
+	want := b.CreateURL("examples/02.jpg", Width(700), Blur(4), FormatAuto())
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+	if got := b.Image("examples/02.jpg").URL(); got != b.CreateURL("examples/02.jpg", CDN()) {
+		t.Errorf("default operation: got %s", got)
+	}
+}
+
+func TestImageRequest_If(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	for _, blurred := range []bool{false, true} {
+		got := b.Image("a.jpg").Cover(300, 200).
+			If(blurred, func(r *ImageRequest) { r.Blur(4) }).
+			Quality(80).
+			URL()
+		want := "https://img.imageboss.me/demo/cover/300x200/quality:80/a.jpg"
+		if blurred {
+			want = "https://img.imageboss.me/demo/cover/300x200/blur:4/quality:80/a.jpg"
+		}
+		if got != want {
+			t.Errorf("blurred=%v:\ngot:  %s\nwant: %s", blurred, got, want)
+		}
+	}
+}
+
+func TestImageRequest_Err(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	r := b.Image("").Width(0).Blur(50).Quality(80)
+	err := r.Err()
+	for _, want := range []error{ErrEmptyPath, ErrInvalidDimension, ErrInvalidOption} {
+		if !errors.Is(err, want) {
+			t.Errorf("Err() = %v; want it to include %v", err, want)
+		}
+	}
+	if got := r.URL(); got != "" {
+		t.Errorf("URL() = %q; want empty on error", got)
+	}
+	if got := r.Srcset(); got != "" {
+		t.Errorf("Srcset() = %q; want empty on error", got)
+	}
+	if err := b.Image("a.jpg").Width(100).Err(); err != nil {
+		t.Errorf("Err() = %v; want nil", err)
+	}
+}
+
+func TestImageRequest_Srcset(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	got := b.Image("image.png").Option(FormatAuto()).Srcset(WithMinWidth(100), WithMaxWidth(380))
+	want := b.CreateSrcset("image.png", CDN(), []Option{FormatAuto()}, WithMinWidth(100), WithMaxWidth(380))
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+	r := b.Image("image.png")
+	if got := r.Srcset(WithTolerance(0)); got != "" || !errors.Is(r.Err(), ErrInvalidTolerance) {
+		t.Errorf("Srcset(bad tolerance) = %q, Err() = %v", got, r.Err())
+	}
+}
+
+func TestImageRequest_Clone(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	base := b.Image("a.jpg").Format(WebP)
+	small := base.Clone().Width(100)
+	large := base.Clone().Width(800).Blur(2)
+	if got := small.URL(); got != "https://img.imageboss.me/demo/width/100/format:webp/a.jpg" {
+		t.Errorf("small = %s", got)
+	}
+	if got := large.URL(); got != "https://img.imageboss.me/demo/width/800/format:webp/blur:2/a.jpg" {
+		t.Errorf("large = %s", got)
+	}
+	if got := base.URL(); got != "https://img.imageboss.me/demo/cdn/format:webp/a.jpg" {
+		t.Errorf("base = %s", got)
+	}
+}
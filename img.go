@@ -0,0 +1,322 @@
+package imageboss
+
+import (
+	"html"
+	"html/template"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultFallbackWidth is the width Img aims for when choosing the src of a
+// fluid (width-based) srcset.
+const DefaultFallbackWidth = 1024
+
+var attrNameRegexp = regexp.MustCompile(`^[a-zA-Z_:][-a-zA-Z0-9_:.]*$`)
+
+// imgAttrs are the attributes Img renders itself; WithAttr cannot set them.
+var imgAttrs = map[string]bool{
+	"src": true, "srcset": true, "sizes": true, "width": true, "height": true,
+	"alt": true, "loading": true, "decoding": true, "fetchpriority": true,
+}
+
+// attrValues are the values allowed for the enumerated attributes.
+var attrValues = map[string]map[string]bool{
+	"loading":       {"lazy": true, "eager": true},
+	"decoding":      {"async": true, "sync": true, "auto": true},
+	"fetchpriority": {"high": true, "low": true, "auto": true},
+}
+
+// ImgOption configures the element rendered by Img or Picture.
+type ImgOption func(*imgConfig)
+
+type imgConfig struct {
+	alt           string
+	loading       string
+	decoding      string
+	fetchPriority string
+	sizes         *Sizes
+	srcsetOpts    []SrcsetOption
+	fallbackWidth int
+	attrs         [][2]string
+
+	// Picture only.
+	sourceFormats  []ImageFormat
+	fallbackFormat ImageFormat
+	variants       []Variant // also ArtDirection
+}
+
+// WithAlt sets the alt text. Img always renders alt, empty by default, which
+// marks the image as decorative.
+func WithAlt(alt string) ImgOption {
+	return func(c *imgConfig) {
+		c.alt = alt
+	}
+}
+
+// WithLoading sets the loading attribute: "lazy" or "eager".
+func WithLoading(loading string) ImgOption {
+	return func(c *imgConfig) {
+		c.loading = loading
+	}
+}
+
+// WithDecoding sets the decoding attribute: "async", "sync" or "auto".
+func WithDecoding(decoding string) ImgOption {
+	return func(c *imgConfig) {
+		c.decoding = decoding
+	}
+}
+
+// WithFetchPriority sets the fetchpriority attribute: "high", "low" or "auto".
+func WithFetchPriority(priority string) ImgOption {
+	return func(c *imgConfig) {
+		c.fetchPriority = priority
+	}
+}
+
+// WithSizes sets the sizes attribute of a fluid srcset. Unless WithSrcset sets
+// a width range, the srcset widths come from sizes.Widths for 1x–2x screens.
+// Without WithSizes, a fluid srcset is rendered with sizes="100vw".
+func WithSizes(sizes Sizes) ImgOption {
+	return func(c *imgConfig) {
+		c.sizes = &sizes
+	}
+}
+
+// WithSrcset sets the srcset options, as for CreateSrcset. WithIntrinsicSize
+// also lets Img compute the width and height attributes of operations that
+// fix only one dimension.
+func WithSrcset(opts ...SrcsetOption) ImgOption {
+	return func(c *imgConfig) {
+		c.srcsetOpts = append(c.srcsetOpts, opts...)
+	}
+}
+
+// WithFallbackWidth sets the width Img aims for when choosing the src of a
+// fluid srcset (DefaultFallbackWidth if unset). See Srcset.ClosestTo.
+func WithFallbackWidth(w int) ImgOption {
+	return func(c *imgConfig) {
+		c.fallbackWidth = w
+	}
+}
+
+// WithAttr adds an attribute, e.g. WithAttr("class", "hero"). Attributes that
+// Img renders itself and event handler attributes (on...) are not allowed.
+func WithAttr(name, value string) ImgOption {
+	return func(c *imgConfig) {
+		c.attrs = append(c.attrs, [2]string{name, value})
+	}
+}
+
+// Img renders an <img> element for the image:
+//
+//   - src is the 1x srcset entry for a fixed-size operation, or the entry
+//     closest to the fallback width (see WithFallbackWidth) for a fluid one;
+//   - srcset is CreateSrcset's, on one line; sizes accompanies a fluid srcset;
+//   - width and height come from the operation (and WithIntrinsicSize), so the
+//     browser can reserve space before the image loads;
+//   - alt, loading, decoding, fetchpriority and extra attributes come from opts.
+//
+// Attribute values are HTML-escaped, so the result is safe to use in
+// html/template. Invalid attributes are left out; use BuildImg to get an
+// error for them instead.
+func (b *URLBuilder) Img(path string, op Operation, options []Option, opts ...ImgOption) template.HTML {
+	img, _ := b.renderImg(path, op, options, newImgConfig(opts))
+	return img
+}
+
+// BuildImg is like Img but validates its arguments first: the path, operation,
+// options and srcset options as in BuildSrcset, the sizes as in Sizes.Widths,
+// and the attributes. Invalid attributes are reported as a *ValidationError
+// wrapping ErrInvalidAttribute.
+func (b *URLBuilder) BuildImg(path string, op Operation, options []Option, opts ...ImgOption) (template.HTML, error) {
+	c := newImgConfig(opts)
+	if err := c.validate(); err != nil {
+		return "", err
+	}
+	b = b.snapshot()
+	if err := validateSrcsetArgs(path, op, options, newSrcsetOptions(b.config().srcsetDefaults, c.srcsetOpts)); err != nil {
+		return "", err
+	}
+	return b.renderImg(path, op, options, c)
+}
+
+func newImgConfig(opts []ImgOption) imgConfig {
+	c := imgConfig{
+		fallbackWidth:  DefaultFallbackWidth,
+		sourceFormats:  DefaultSourceFormats,
+		fallbackFormat: JPEG,
+	}
+	for _, fn := range opts {
+		fn(&c)
+	}
+	return c
+}
+
+func (c imgConfig) validate() error {
+	for _, a := range c.enumAttrs() {
+		if a[1] != "" && !attrValues[a[0]][a[1]] {
+			return invalid(ErrInvalidAttribute, a[0], a[1], "")
+		}
+	}
+	if c.fallbackWidth <= 0 {
+		return invalid(ErrInvalidAttribute, "fallbackWidth", c.fallbackWidth, "must be positive")
+	}
+	if c.sizes != nil {
+		if _, err := c.sizes.Widths(1, 2, defaultTolerance); err != nil {
+			return err
+		}
+	}
+	for _, a := range c.attrs {
+		if err := validateAttrName(a[0]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c imgConfig) enumAttrs() [][2]string {
+	return [][2]string{{"loading", c.loading}, {"decoding", c.decoding}, {"fetchpriority", c.fetchPriority}}
+}
+
+func validateAttrName(name string) error {
+	lower := strings.ToLower(name)
+	switch {
+	case !attrNameRegexp.MatchString(name):
+		return invalid(ErrInvalidAttribute, "name", name, "")
+	case imgAttrs[lower]:
+		return invalid(ErrInvalidAttribute, "name", name, "set by Img")
+	case strings.HasPrefix(lower, "on"):
+		return invalid(ErrInvalidAttribute, "name", name, "event handlers are not allowed")
+	}
+	return nil
+}
+
+// renderImg renders the <img> element. On an error it returns the element
+// as Img renders it and the error.
+func (b *URLBuilder) renderImg(path string, op Operation, options []Option, c imgConfig) (template.HTML, error) {
+	b = b.snapshot()
+	var sb strings.Builder
+	err := b.writeImg(&sb, path, op, options, c)
+	return template.HTML(sb.String()), err
+}
+
+// writeImg writes the <img> element for Img and Picture. On a signing error
+// it writes the element with unsigned URLs and returns the error.
+func (b *URLBuilder) writeImg(sb *strings.Builder, path string, op Operation, options []Option, c imgConfig) error {
+	srcset, fixed, err := b.imgSrcset(path, op, options, c)
+	var src string
+	if fixed {
+		for _, e := range srcset {
+			if e.Density == 1 {
+				src = e.URL // the 1x entry, so the browser can reuse it
+				break
+			}
+		}
+	} else {
+		e, _ := srcset.ClosestTo(c.fallbackWidth)
+		src = e.URL
+	}
+	if src == "" {
+		var srcErr error
+		if src, srcErr = b.config().createURL(path, op, options); err == nil {
+			err = srcErr
+		}
+	}
+
+	sb.WriteString("<img")
+	writeAttr(sb, "src", src)
+	c.writeSrcset(sb, srcset, fixed)
+	o := newSrcsetOptions(b.config().srcsetDefaults, c.srcsetOpts)
+	if w, h := imgDimensions(op, o.IntrinsicWidth, o.IntrinsicHeight); w > 0 && h > 0 {
+		writeAttr(sb, "width", strconv.Itoa(w))
+		writeAttr(sb, "height", strconv.Itoa(h))
+	}
+	writeAttr(sb, "alt", c.alt)
+	for _, a := range c.enumAttrs() {
+		if a[1] != "" && attrValues[a[0]][a[1]] {
+			writeAttr(sb, a[0], a[1])
+		}
+	}
+	for _, a := range c.attrs {
+		if validateAttrName(a[0]) == nil {
+			writeAttr(sb, a[0], a[1])
+		}
+	}
+	sb.WriteString(">")
+	return err
+}
+
+// imgSrcset returns the srcset for an <img> or <source> and whether it is
+// density-based. On invalid sizes, which leave the width range as the srcset
+// options set it, or a signing error it also returns the error.
+func (b *URLBuilder) imgSrcset(path string, op Operation, options []Option, c imgConfig) (Srcset, bool, error) {
+	srcsetOpts := c.srcsetOpts
+	var sizesErr error
+	if c.sizes != nil {
+		var widths []int
+		if widths, sizesErr = c.sizes.Widths(1, 2, defaultTolerance); sizesErr == nil {
+			// Prepended, so a width range set with WithSrcset still wins.
+			srcsetOpts = append([]SrcsetOption{WithMinWidth(widths[0]), WithMaxWidth(widths[len(widths)-1])}, srcsetOpts...)
+		}
+	}
+	srcset, err := b.srcset(path, op, options, newSrcsetOptions(b.config().srcsetDefaults, srcsetOpts))
+	if sizesErr != nil {
+		err = sizesErr
+	}
+	return srcset, len(srcset) == 0 || srcset[0].Width == 0, err
+}
+
+// writeSrcset writes the srcset attribute and, for a fluid srcset, sizes.
+func (c imgConfig) writeSrcset(sb *strings.Builder, srcset Srcset, fixed bool) {
+	if len(srcset) == 0 {
+		return
+	}
+	writeAttr(sb, "srcset", srcset.Compact())
+	if !fixed {
+		sizes := Sizes{}
+		if c.sizes != nil {
+			sizes = *c.sizes
+		}
+		writeAttr(sb, "sizes", sizes.String())
+	}
+}
+
+// writeAttr writes ` name="value"` with value HTML-escaped.
+func writeAttr(sb *strings.Builder, name, value string) {
+	sb.WriteString(" " + name + `="` + html.EscapeString(value) + `"`)
+}
+
+// imgDimensions returns the size of op's output in pixels, using the intrinsic
+// size of the source image for dimensions op does not fix. It returns 0 for a
+// dimension it cannot tell.
+func imgDimensions(op Operation, intrinsicW, intrinsicH int) (int, int) {
+	w, h := op.Size()
+	known := intrinsicW > 0 && intrinsicH > 0
+	switch {
+	case w > 0 && h > 0:
+	case w > 0 && known:
+		h = int(math.Round(float64(w) * float64(intrinsicH) / float64(intrinsicW)))
+	case h > 0 && known:
+		w = int(math.Round(float64(h) * float64(intrinsicW) / float64(intrinsicH)))
+	case w == 0 && h == 0:
+		w, h = intrinsicW, intrinsicH
+	}
+	return w, h
+}
+
+// Img renders an <img> element for the request (see URLBuilder.Img), or ""
+// if Err is not nil. Invalid attributes are recorded and reported by Err.
+func (r *ImageRequest) Img(opts ...ImgOption) template.HTML {
+	if r.Err() != nil {
+		return ""
+	}
+	img, err := r.b.BuildImg(r.path, r.op, r.options, opts...)
+	if err != nil {
+		r.errs = append(r.errs, err)
+		return ""
+	}
+	return img
+}
@@ -0,0 +1,111 @@
+package imageboss
+
+import (
+	"errors"
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestImg_Fixed(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	got := b.Img("a.jpg", Cover(300, 200), nil,
+		WithAlt(`Tom & "Jerry"`), WithLoading("lazy"), WithDecoding("async"), WithFetchPriority("low"),
+		WithSrcset(WithDensities(1, 2), WithVariableQuality(false)), WithAttr("class", "thumb"))
+	want := `<img src="https://img.imageboss.me/demo/cover/300x200/a.jpg"` +
+		` srcset="https://img.imageboss.me/demo/cover/300x200/a.jpg 1x, https://img.imageboss.me/demo/cover/300x200/dpr:2/a.jpg 2x"` +
+		` width="300" height="200" alt="Tom &amp; &#34;Jerry&#34;" loading="lazy" decoding="async" fetchpriority="low" class="thumb">`
+	if got != template.HTML(want) {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestImg_Fluid(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	sizes := Sizes{}.When(MediaMaxWidth(640), VW(100)).Default(Px(320))
+	got := string(b.Img("a.jpg", CDN(), nil, WithSizes(sizes), WithSrcset(WithIntrinsicSize(4000, 3000))))
+	for _, want := range []string{
+		`<img src="https://img.imageboss.me/demo/width/1049/a.jpg"`,
+		`https://img.imageboss.me/demo/width/320/a.jpg 320w, `,
+		` sizes="(max-width: 640px) 100vw, 320px"`,
+		` width="4000" height="3000" alt="">`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "width/1446/") {
+		t.Errorf("srcset exceeds sizes:\n%s", got)
+	}
+
+	got = string(b.Img("a.jpg", CDN(), nil, WithSrcset(WithMinWidth(100), WithMaxWidth(500)), WithFallbackWidth(300)))
+	if !strings.HasPrefix(got, `<img src="https://img.imageboss.me/demo/width/328/a.jpg"`) || !strings.Contains(got, ` sizes="100vw"`) {
+		t.Errorf("fallback or default sizes:\n%s", got)
+	}
+	if strings.Contains(got, " width=") {
+		t.Errorf("width without intrinsic size:\n%s", got)
+	}
+}
+
+func TestImg_Dimensions(t *testing.T) {
+	tests := []struct {
+		op           Operation
+		iw, ih       int
+		wantW, wantH int
+	}{
+		{Width(700), 0, 0, 700, 0},
+		{Width(700), 1400, 1000, 700, 500},
+		{Height(300), 1600, 900, 533, 300},
+		{CoverAspect(800, "16:9"), 0, 0, 800, 450},
+		{CDN(), 1200, 800, 1200, 800},
+	}
+	for _, tt := range tests {
+		if w, h := imgDimensions(tt.op, tt.iw, tt.ih); w != tt.wantW || h != tt.wantH {
+			t.Errorf("imgDimensions(%+v, %d, %d) = %d, %d; want %d, %d", tt.op, tt.iw, tt.ih, w, h, tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestBuildImg_Invalid(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	tests := []struct {
+		path string
+		op   Operation
+		opts []ImgOption
+		want error
+	}{
+		{"a.jpg", Width(100), []ImgOption{WithLoading("later")}, ErrInvalidAttribute},
+		{"a.jpg", Width(100), []ImgOption{WithFetchPriority("urgent")}, ErrInvalidAttribute},
+		{"a.jpg", Width(100), []ImgOption{WithAttr("onerror", "alert(1)")}, ErrInvalidAttribute},
+		{"a.jpg", Width(100), []ImgOption{WithAttr("src", "x.jpg")}, ErrInvalidAttribute},
+		{"a.jpg", Width(100), []ImgOption{WithAttr(`"><script>`, "")}, ErrInvalidAttribute},
+		{"a.jpg", Width(100), []ImgOption{WithFallbackWidth(0)}, ErrInvalidAttribute},
+		{"", Width(100), nil, ErrEmptyPath},
+		{"a.jpg", Width(100), []ImgOption{WithSrcset(WithDensities(9))}, ErrInvalidDensity},
+		{"a.jpg", CDN(), []ImgOption{WithSizes(Sizes{}.Default(Px(-5)))}, ErrInvalidSizes},
+		{"a.jpg", CDN(), []ImgOption{WithSizes(Sizes{}.When(MediaMaxWidth(600), VW(0)))}, ErrInvalidSizes},
+		{"a.jpg", CDN(), []ImgOption{WithSizes(Sizes{}.Viewport(800, 400))}, ErrInvalidSizes},
+	}
+	for _, tt := range tests {
+		if _, err := b.BuildImg(tt.path, tt.op, nil, tt.opts...); !errors.Is(err, tt.want) {
+			t.Errorf("BuildImg(%q, %+v) = %v; want %v", tt.path, tt.op, err, tt.want)
+		}
+	}
+
+	// Img leaves invalid attributes out.
+	got := string(b.Img("a.jpg", Width(100), nil, WithLoading("later"), WithAttr("onerror", "alert(1)"), WithAttr("data-id", "7")))
+	if strings.Contains(got, "loading") || strings.Contains(got, "onerror") || !strings.Contains(got, ` data-id="7"`) {
+		t.Errorf("Img kept invalid attributes:\n%s", got)
+	}
+}
+
+func TestImageRequest_Img(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	r := b.Image("a.jpg").Width(300)
+	if got, want := r.Img(WithAlt("a")), b.Img("a.jpg", Width(300), nil, WithAlt("a")); got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+	if got := r.Img(WithLoading("soon")); got != "" || !errors.Is(r.Err(), ErrInvalidAttribute) {
+		t.Errorf("Img(invalid) = %q, Err() = %v", got, r.Err())
+	}
+}
@@ -2,62 +2,187 @@ package imageboss
 
 import (
 	"fmt"
+	"math"
 	"strconv"
+	"strings"
 )
 
-// Operation represents an ImageBoss operation (cdn, width, height, cover).
-type Operation struct {
-	kind   string // "cdn", "width", "height", "cover"
-	width  int    // for width, cover
-	height int    // for height, cover
-	mode   string // for cover: "", "center", "smart", "attention", "entropy", "face", "north", etc.
+// MaxDimension is the largest width or height ImageBoss will output.
+const MaxDimension = 8192
+
+// coverModes are the cover modes (crop strategies and gravities) ImageBoss supports.
+var coverModes = map[string]bool{
+	"center": true, "smart": true, "attention": true, "entropy": true, "face": true,
+	"north": true, "northeast": true, "east": true, "southeast": true,
+	"south": true, "southwest": true, "west": true, "northwest": true,
 }
 
-// PathSegment returns the operation path segment (e.g. "cdn", "width", "cover:center").
-func (o Operation) PathSegment() string {
-	if o.mode != "" {
-		return o.kind + ":" + o.mode
-	}
-	return o.kind
+// Operation represents an ImageBoss operation (cdn, width, height, cover).
+//
+// CDN, Width, Height, Cover and CoverMode return the built-in operations.
+// Implement Operation to use other ImageBoss operations: CreateURL and the
+// srcset functions only use these methods.
+type Operation interface {
+	// PathSegment returns the operation path segment (e.g. "cdn", "width", "cover:center").
+	PathSegment() string
+	// Dimensions returns the dimensions segment (e.g. "" for cdn, "700" for width, "300x300" for cover).
+	Dimensions() string
+	// Size returns the output width and height in pixels; 0 means the
+	// dimension follows the source image. CreateSrcset generates a
+	// density-based srcset when either is non-zero and a fluid one otherwise.
+	Size() (width, height int)
+	// ResizeToWidth returns the operation for the entry of width w in a
+	// fluid (width-based) srcset.
+	ResizeToWidth(w int) Operation
+	// Validate returns an error if the operation cannot be rendered.
+	Validate() error
 }
 
-// Dimensions returns the dimensions segment (e.g. "" for cdn, "700" for width, "300x300" for cover).
-func (o Operation) Dimensions() string {
-	switch o.kind {
-	case "cdn":
-		return ""
-	case "width":
-		return strconv.Itoa(o.width)
-	case "height":
-		return strconv.Itoa(o.height)
-	case "cover":
-		return fmt.Sprintf("%dx%d", o.width, o.height)
-	default:
-		return ""
+// validateOperation is op.Validate that also rejects a nil Operation.
+func validateOperation(op Operation) error {
+	if op == nil {
+		return invalid(ErrInvalidOperation, "operation", nil, "")
 	}
+	return op.Validate()
 }
 
 // CDN returns a pass-through CDN operation (no resize).
 func CDN() Operation {
-	return Operation{kind: "cdn"}
+	return cdnOperation{}
 }
 
+type cdnOperation struct{}
+
+func (cdnOperation) PathSegment() string           { return "cdn" }
+func (cdnOperation) Dimensions() string            { return "" }
+func (cdnOperation) Size() (int, int)              { return 0, 0 }
+func (cdnOperation) ResizeToWidth(w int) Operation { return Width(w) }
+func (cdnOperation) Validate() error               { return nil }
+
 // Width returns a width operation (fixed width, proportional height).
 func Width(w int) Operation {
-	return Operation{kind: "width", width: w}
+	return widthOperation{width: w}
+}
+
+type widthOperation struct {
+	width int
 }
 
+func (o widthOperation) PathSegment() string           { return "width" }
+func (o widthOperation) Dimensions() string            { return strconv.Itoa(o.width) }
+func (o widthOperation) Size() (int, int)              { return o.width, 0 }
+func (o widthOperation) ResizeToWidth(w int) Operation { return Width(w) }
+func (o widthOperation) Validate() error               { return validateDimension("width", o.width) }
+
 // Height returns a height operation (fixed height, proportional width).
 func Height(h int) Operation {
-	return Operation{kind: "height", height: h}
+	return heightOperation{height: h}
+}
+
+type heightOperation struct {
+	height int
 }
 
+func (o heightOperation) PathSegment() string { return "height" }
+func (o heightOperation) Dimensions() string  { return strconv.Itoa(o.height) }
+func (o heightOperation) Size() (int, int)    { return 0, o.height }
+func (o heightOperation) Validate() error     { return validateDimension("height", o.height) }
+
+// ResizeToWidth returns Width(w): the entry of width w has a height that
+// follows the source's aspect ratio.
+func (o heightOperation) ResizeToWidth(w int) Operation { return Width(w) }
+
 // Cover returns a cover operation (exact width and height, default smart crop).
 func Cover(w, h int) Operation {
-	return Operation{kind: "cover", width: w, height: h}
+	return coverOperation{width: w, height: h}
 }
 
 // CoverMode returns a cover operation with a mode (center, smart, attention, entropy, face, north, etc.).
 func CoverMode(w, h int, mode string) Operation {
-	return Operation{kind: "cover", width: w, height: h, mode: mode}
+	return coverOperation{width: w, height: h, mode: mode}
+}
+
+// CoverAspect returns a cover operation w pixels wide with the aspect ratio
+// given as "W:H" (e.g. "16:9" or "1.91:1"); the height is rounded to the
+// nearest pixel. An invalid ratio is reported by Validate.
+func CoverAspect(w int, ratio string) Operation {
+	return CoverAspectMode(w, ratio, "")
+}
+
+// CoverAspectMode is CoverAspect with a mode. See CoverMode.
+func CoverAspectMode(w int, ratio, mode string) Operation {
+	aspect, err := parseAspectRatio(ratio)
+	if err != nil {
+		return coverOperation{width: w, mode: mode, err: err}
+	}
+	return coverOperation{width: w, height: scaleHeight(w, aspect), mode: mode}
+}
+
+// parseAspectRatio parses a "W:H" ratio and returns W/H.
+func parseAspectRatio(ratio string) (float64, error) {
+	ws, hs, ok := strings.Cut(ratio, ":")
+	if !ok {
+		return 0, invalid(ErrInvalidAspectRatio, "ratio", ratio, "want W:H")
+	}
+	w, errW := strconv.ParseFloat(ws, 64)
+	h, errH := strconv.ParseFloat(hs, 64)
+	if errW != nil || errH != nil || !(w > 0) || !(h > 0) || math.IsInf(w, 0) || math.IsInf(h, 0) {
+		return 0, invalid(ErrInvalidAspectRatio, "ratio", ratio, "want W:H with positive numbers")
+	}
+	return w / h, nil
+}
+
+// scaleHeight returns the height of a w pixels wide image with aspect ratio
+// aspect (width / height), at least 1.
+func scaleHeight(w int, aspect float64) int {
+	return max(1, int(math.Round(float64(w)/aspect)))
+}
+
+type coverOperation struct {
+	width  int
+	height int
+	mode   string // "", "center", "smart", "attention", "entropy", "face", "north", etc.
+	err    error  // set by CoverAspect for an invalid ratio
+}
+
+func (o coverOperation) PathSegment() string {
+	if o.mode != "" {
+		return "cover:" + o.mode
+	}
+	return "cover"
+}
+
+func (o coverOperation) Dimensions() string {
+	return fmt.Sprintf("%dx%d", o.width, o.height)
+}
+
+func (o coverOperation) Size() (int, int) {
+	return o.width, o.height
+}
+
+// ResizeToWidth returns a cover crop w pixels wide with the same aspect ratio
+// and mode, so each fluid srcset entry is the same crop at a different size.
+func (o coverOperation) ResizeToWidth(w int) Operation {
+	if o.width <= 0 || o.height <= 0 {
+		return o
+	}
+	o.height = scaleHeight(w, float64(o.width)/float64(o.height))
+	o.width = w
+	return o
+}
+
+func (o coverOperation) Validate() error {
+	if o.err != nil {
+		return o.err
+	}
+	if err := validateDimension("width", o.width); err != nil {
+		return err
+	}
+	if err := validateDimension("height", o.height); err != nil {
+		return err
+	}
+	if o.mode != "" && !coverModes[o.mode] {
+		return invalid(ErrUnknownCoverMode, "mode", o.mode, "")
+	}
+	return nil
 }
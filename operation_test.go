@@ -1,6 +1,8 @@
 package imageboss
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 )
 
@@ -40,3 +42,118 @@ func TestOperation_Dimensions(t *testing.T) {
 		}
 	}
 }
+
+func TestOperation_Validate(t *testing.T) {
+	valid := []Operation{CDN(), Width(700), Height(MaxDimension), Cover(300, 300), CoverMode(320, 320, "face")}
+	for _, op := range valid {
+		if err := op.Validate(); err != nil {
+			t.Errorf("%+v.Validate() = %v", op, err)
+		}
+	}
+	tests := []struct {
+		op   Operation
+		want error
+	}{
+		{Width(0), ErrInvalidDimension},
+		{Height(-1), ErrInvalidDimension},
+		{Cover(-5, 300), ErrInvalidDimension},
+		{Cover(300, 0), ErrInvalidDimension},
+		{Width(MaxDimension + 1), ErrDimensionTooLarge},
+		{CoverMode(300, 300, "middle"), ErrUnknownCoverMode},
+		{CoverAspect(300, "16x9"), ErrInvalidAspectRatio},
+		{CoverAspect(300, "16:0"), ErrInvalidAspectRatio},
+		{CoverAspectMode(300, "-1:1", "face"), ErrInvalidAspectRatio},
+	}
+	for _, tt := range tests {
+		if err := tt.op.Validate(); !errors.Is(err, tt.want) {
+			t.Errorf("%+v.Validate() = %v; want %v", tt.op, err, tt.want)
+		}
+	}
+}
+
+// fitOperation is a custom operation used to check that Operation can be
+// implemented outside the built-in constructors.
+type fitOperation struct{ width, height int }
+
+func (o fitOperation) PathSegment() string { return "fit" }
+func (o fitOperation) Dimensions() string  { return fmt.Sprintf("%dx%d", o.width, o.height) }
+func (o fitOperation) Size() (int, int)    { return o.width, o.height }
+func (o fitOperation) Validate() error     { return nil }
+func (o fitOperation) ResizeToWidth(w int) Operation {
+	return fitOperation{w, o.height * w / o.width}
+}
+
+func TestOperation_Custom(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	if got, want := b.CreateURL("a.jpg", fitOperation{400, 300}), "https://img.imageboss.me/demo/fit/400x300/a.jpg"; got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+	got := b.CreateSrcsetFromWidths("a.jpg", fitOperation{400, 300}, nil, []int{200, 800})
+	want := "https://img.imageboss.me/demo/fit/200x150/a.jpg 200w,\n" +
+		"https://img.imageboss.me/demo/fit/800x600/a.jpg 800w"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+	if _, err := b.BuildURL("a.jpg", nil); !errors.Is(err, ErrInvalidOperation) {
+		t.Errorf("BuildURL(nil operation) err = %v; want ErrInvalidOperation", err)
+	}
+}
+
+func TestOperation_Size(t *testing.T) {
+	tests := []struct {
+		op   Operation
+		w, h int
+	}{
+		{CDN(), 0, 0},
+		{Width(700), 700, 0},
+		{Height(500), 0, 500},
+		{CoverMode(320, 240, "face"), 320, 240},
+	}
+	for _, tt := range tests {
+		if w, h := tt.op.Size(); w != tt.w || h != tt.h {
+			t.Errorf("%+v.Size() = %d, %d; want %d, %d", tt.op, w, h, tt.w, tt.h)
+		}
+	}
+}
+
+func TestCoverAspect(t *testing.T) {
+	tests := []struct {
+		op   Operation
+		want string
+	}{
+		{CoverAspect(800, "16:9"), "cover/800x450"},
+		{CoverAspect(1000, "16:9"), "cover/1000x563"},
+		{CoverAspect(1200, "1.91:1"), "cover/1200x628"},
+		{CoverAspectMode(300, "1:1", "face"), "cover:face/300x300"},
+	}
+	for _, tt := range tests {
+		if err := tt.op.Validate(); err != nil {
+			t.Errorf("%+v.Validate() = %v", tt.op, err)
+		}
+		if got := tt.op.PathSegment() + "/" + tt.op.Dimensions(); got != tt.want {
+			t.Errorf("got %s; want %s", got, tt.want)
+		}
+	}
+}
+
+func TestOperation_ResizeToWidth(t *testing.T) {
+	tests := []struct {
+		op   Operation
+		w    int
+		want string
+	}{
+		{CDN(), 300, "width/300"},
+		{Width(800), 300, "width/300"},
+		{Height(800), 300, "width/300"},
+		{Cover(800, 450), 400, "cover/400x225"},
+		{CoverMode(800, 450, "face"), 100, "cover:face/100x56"},
+		{Cover(300, 600), 150, "cover/150x300"},
+		{Cover(1000, 10), 10, "cover/10x1"},
+	}
+	for _, tt := range tests {
+		op := tt.op.ResizeToWidth(tt.w)
+		if got := op.PathSegment() + "/" + op.Dimensions(); got != tt.want {
+			t.Errorf("%+v.ResizeToWidth(%d) = %s; want %s", tt.op, tt.w, got, tt.want)
+		}
+	}
+}
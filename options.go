@@ -1,11 +1,16 @@
 package imageboss
 
 import (
-	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
+	"unicode"
 )
 
+var hexColorRegexp = regexp.MustCompile(`^([0-9a-fA-F]{3}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+
 // Option is a path-segment option (e.g. blur:4, format:auto).
+// Options may also implement Validate() error; ValidateOptions calls it.
 type Option interface {
 	PathSegment() string
 }
@@ -30,19 +35,183 @@ func (p paramOption) PathSegment() string {
 	return p.key + ":" + strings.Join(p.values, ",")
 }
 
+// checkedOption is a paramOption built by a typed constructor. err is set when
+// the value is outside the documented range; the segment is still rendered so
+// CreateURL output is unchanged, and ValidateOptions reports the error.
+type checkedOption struct {
+	paramOption
+	err error
+}
+
+func (o checkedOption) Validate() error {
+	return o.err
+}
+
+func checked(key, value string, err error) Option {
+	return checkedOption{paramOption{key: key, values: []string{value}}, err}
+}
+
+// intRangeOption returns key:n, invalid unless min <= n <= max.
+func intRangeOption(key string, n, min, max int) Option {
+	var err error
+	if n < min || n > max {
+		err = invalid(ErrInvalidOption, key, n, "out of range %d–%d", min, max)
+	}
+	return checked(key, strconv.Itoa(n), err)
+}
+
+// ValidateOptions returns the first error reported by an option's Validate method.
+// Options built by the typed constructors (Blur, Quality, Format, ...) validate
+// their documented ranges; Param and Opt options are never invalid.
+func ValidateOptions(options ...Option) error {
+	for _, o := range options {
+		if v, ok := o.(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // Opt creates a single key:value path segment for ImageBoss options.
 func Opt(key, value string) Option {
 	return Param(key, value)
 }
 
+// NoDefaults returns an Option that leaves out the builder's default options
+// (see WithDefaultOptions) for one call. With keys, only defaults with those
+// keys are left out:
+//
+//	b.CreateURL(path, imageboss.CDN(), imageboss.Download(), imageboss.NoDefaults())
+//	b.CreateURL(path, imageboss.CDN(), imageboss.NoDefaults("format"))
+//
+// It renders no path segment.
+func NoDefaults(keys ...string) Option {
+	return noDefaultsOption{keys: keys}
+}
+
+type noDefaultsOption struct {
+	keys []string
+}
+
+func (noDefaultsOption) PathSegment() string { return "" }
+
+// optionKey returns the key of an option's path segment, e.g. "blur" for "blur:4".
+func optionKey(o Option) string {
+	key, _, _ := strings.Cut(o.PathSegment(), ":")
+	return key
+}
+
+// mergeOptions returns defaults followed by options, leaving out defaults whose
+// key an option also sets and defaults excluded by NoDefaults.
+func mergeOptions(defaults, options []Option) []Option {
+	if len(defaults) == 0 {
+		return options
+	}
+	skip := make(map[string]bool)
+	for _, o := range options {
+		if nd, ok := o.(noDefaultsOption); ok {
+			if len(nd.keys) == 0 {
+				return options
+			}
+			for _, k := range nd.keys {
+				skip[k] = true
+			}
+			continue
+		}
+		if key := optionKey(o); key != "" {
+			skip[key] = true
+		}
+	}
+	merged := make([]Option, 0, len(defaults)+len(options))
+	for _, d := range defaults {
+		if !skip[optionKey(d)] {
+			merged = append(merged, d)
+		}
+	}
+	return append(merged, options...)
+}
+
+// ImageFormat is an output format for the format option.
+type ImageFormat string
+
+// Output formats supported by ImageBoss. Auto picks the best format the
+// browser accepts (AVIF, WebP or the original).
+const (
+	Auto ImageFormat = "auto"
+	JPEG ImageFormat = "jpg"
+	PNG  ImageFormat = "png"
+	WebP ImageFormat = "webp"
+	AVIF ImageFormat = "avif"
+)
+
+// Format adds format:<f> option (auto, jpg, png, webp or avif).
+func Format(f ImageFormat) Option {
+	var err error
+	switch f {
+	case Auto, JPEG, PNG, WebP, AVIF:
+	default:
+		err = invalid(ErrInvalidOption, "format", string(f), "unknown format")
+	}
+	return checked("format", string(f), err)
+}
+
 // FormatAuto adds format:auto option.
 func FormatAuto() Option {
-	return Opt("format", "auto")
+	return Format(Auto)
+}
+
+// Quality adds quality:N option (1–100).
+func Quality(q int) Option {
+	return intRangeOption("quality", q, 1, 100)
 }
 
 // Blur adds blur:N option (0–40, 2–4 recommended).
 func Blur(n int) Option {
-	return Opt("blur", fmt.Sprintf("%d", n))
+	return intRangeOption("blur", n, 0, 40)
+}
+
+// Sharpen adds sharpen:N option (1–10).
+func Sharpen(n int) Option {
+	return intRangeOption("sharpen", n, 1, 10)
+}
+
+// DPR adds dpr:N option (1–5), multiplying the output dimensions by the device
+// pixel ratio.
+func DPR(dpr float64) Option {
+	var err error
+	if dpr < 1 || dpr > 5 {
+		err = invalid(ErrInvalidOption, "dpr", dpr, "out of range 1–5")
+	}
+	return checked("dpr", strconv.FormatFloat(dpr, 'f', -1, 64), err)
+}
+
+// Grayscale adds grayscale:true option.
+func Grayscale() Option {
+	return Opt("grayscale", "true")
+}
+
+// Progressive adds progressive:true|false option (progressive JPEG / interlaced PNG).
+func Progressive(enabled bool) Option {
+	return Opt("progressive", strconv.FormatBool(enabled))
+}
+
+// Animation adds animation:true|false option. Use Animation(false) to output
+// only the first frame of an animated image.
+func Animation(enabled bool) Option {
+	return Opt("animation", strconv.FormatBool(enabled))
+}
+
+// FillColor adds fill-color:<hex> option, used for transparent areas and
+// padding. The color is a 3, 6 or 8 digit hex value, with or without "#".
+func FillColor(color string) Option {
+	color = strings.TrimPrefix(color, "#")
+	var err error
+	if !hexColorRegexp.MatchString(color) {
+		err = invalid(ErrInvalidOption, "fill-color", color, "not a hex color")
+	}
+	return checked("fill-color", color, err)
 }
 
 // Download adds download option (force download). Use DownloadFilename for custom name.
@@ -50,7 +219,12 @@ func Download() Option {
 	return Opt("download", "1")
 }
 
-// DownloadFilename adds download:filename option.
+// DownloadFilename adds download:filename option. The filename is used in the
+// URL path as is, so it must not contain /, ?, #, %, commas or whitespace.
 func DownloadFilename(filename string) Option {
-	return Opt("download", filename)
+	var err error
+	if filename == "" || strings.ContainsAny(filename, "/?#%,") || strings.IndexFunc(filename, unicode.IsSpace) >= 0 {
+		err = invalid(ErrInvalidOption, "download", filename, "must be non-empty and contain no /, ?, #, %, comma or whitespace")
+	}
+	return checked("download", filename, err)
 }
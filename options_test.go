@@ -1,6 +1,7 @@
 package imageboss
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -23,3 +24,61 @@ func TestParam_PathSegment(t *testing.T) {
 		}
 	}
 }
+
+func TestTypedOptions_PathSegment(t *testing.T) {
+	tests := []struct {
+		opt  Option
+		want string
+	}{
+		{Quality(80), "quality:80"},
+		{Format(WebP), "format:webp"},
+		{Format(AVIF), "format:avif"},
+		{Grayscale(), "grayscale:true"},
+		{FillColor("#FFFFFF"), "fill-color:FFFFFF"},
+		{FillColor("000"), "fill-color:000"},
+		{DPR(2), "dpr:2"},
+		{DPR(1.5), "dpr:1.5"},
+		{Progressive(true), "progressive:true"},
+		{Sharpen(3), "sharpen:3"},
+		{Animation(false), "animation:false"},
+	}
+	for _, tt := range tests {
+		if got := tt.opt.PathSegment(); got != tt.want {
+			t.Errorf("PathSegment() = %s; want %s", got, tt.want)
+		}
+		if err := ValidateOptions(tt.opt); err != nil {
+			t.Errorf("ValidateOptions(%s) = %v", tt.want, err)
+		}
+	}
+}
+
+func TestValidateOptions_Invalid(t *testing.T) {
+	invalid := []Option{
+		Blur(-1),
+		Blur(41),
+		Quality(0),
+		Quality(101),
+		Sharpen(0),
+		DPR(0.5),
+		DPR(6),
+		Format("jpeg2000"),
+		FillColor("white"),
+		DownloadFilename(""),
+		DownloadFilename("a/b.png"),
+		DownloadFilename("a?b.png"),
+		DownloadFilename("a#b.png"),
+		DownloadFilename("a%20b.png"),
+		DownloadFilename("a,b.png"),
+		DownloadFilename("my image.png"),
+		DownloadFilename("a\tb.png"),
+	}
+	for _, o := range invalid {
+		err := ValidateOptions(FormatAuto(), o)
+		if !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("ValidateOptions(%s) = %v; want ErrInvalidOption", o.PathSegment(), err)
+		}
+	}
+	if err := ValidateOptions(Opt("qualty", "80"), Blur(40)); err != nil {
+		t.Errorf("ValidateOptions(valid) = %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+package imageboss
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var optionSegmentRegexp = regexp.MustCompile(`^[a-z][a-z0-9-]*:[^/]*$`)
+
+// ParsedURL is an ImageBoss URL split into its components.
+type ParsedURL struct {
+	BaseURL   string    // scheme and host, plus any path prefix (e.g. "https://img.imageboss.me")
+	Source    string    // ImageBoss source name
+	Operation Operation // cdn, width, height or cover (with mode)
+	Options   []Option  // path-segment options in URL order
+	Path      string    // unescaped image path relative to the source
+	BossToken string    // bossToken query parameter, empty if the URL is not signed
+
+	escapedPath string // path after the base URL as it appears in the parsed URL
+}
+
+// String reassembles the URL. For a ParsedURL returned by ParseURL this is the
+// URL that was parsed, including its bossToken.
+func (p *ParsedURL) String() string {
+	segments := urlSegments(p.Source, p.Operation, p.Options, sanitizePath(p.Path))
+	urlStr := strings.TrimSuffix(p.BaseURL, "/") + "/" + strings.Join(segments, "/")
+	if p.BossToken != "" {
+		urlStr += "?bossToken=" + p.BossToken
+	}
+	return urlStr
+}
+
+// ParseURL splits an ImageBoss URL into base URL, source, operation, options
+// and image path. The base URL is taken to be the scheme and host; use
+// URLBuilder.ParseURL for base URLs that include a path prefix.
+//
+// Option segments are recognised by their key:value form, so an image path
+// whose first directory looks like "key:value" is read as an option. Bare
+// option segments without a value, such as Param("download"), cannot be told
+// apart from directories and are read as part of Path; build such options
+// with a value (Download gives "download:1") if URLs need to be parsed.
+func ParseURL(rawURL string) (*ParsedURL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, invalid(ErrInvalidURL, "url", rawURL, err.Error())
+	}
+	if u.Host == "" {
+		return nil, invalid(ErrInvalidURL, "url", rawURL, "has no host")
+	}
+	base := "//" + u.Host
+	if u.Scheme != "" {
+		base = u.Scheme + ":" + base
+	}
+	return parseRemainder(base, strings.TrimPrefix(u.EscapedPath(), "/"), u.Query())
+}
+
+// ParseURL is like the package-level ParseURL but strips the builder's base URL,
+// so base URLs with a path prefix are handled, and requires the URL to belong
+// to the builder's source. The URL's scheme may differ from the builder's.
+func (b *URLBuilder) ParseURL(rawURL string) (*ParsedURL, error) {
+	return b.config().parseURL(rawURL)
+}
+
+func (c *builderConfig) parseURL(rawURL string) (*ParsedURL, error) {
+	rest, query, _ := strings.Cut(rawURL, "?")
+	scheme, hostPath, ok := strings.Cut(rest, "//")
+	if !ok || (scheme != "" && !strings.HasSuffix(scheme, ":")) || !strings.HasPrefix(hostPath, c.host+"/") {
+		return nil, invalid(ErrInvalidURL, "url", rawURL, "does not start with base URL %q", c.prefix())
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, invalid(ErrInvalidURL, "url", rawURL, err.Error())
+	}
+	p, err := parseRemainder(scheme+"//"+c.host, strings.TrimPrefix(hostPath, c.host+"/"), values)
+	if err != nil {
+		return nil, err
+	}
+	if p.Source != c.source {
+		return nil, invalid(ErrInvalidURL, "source", p.Source, "does not match builder source %q", c.source)
+	}
+	return p, nil
+}
+
+// parseRemainder parses the escaped path after the base URL:
+// source/operation[/dimensions][/options...]/path.
+func parseRemainder(base, escapedPath string, query url.Values) (*ParsedURL, error) {
+	segments := strings.Split(escapedPath, "/")
+	if len(segments) < 3 {
+		return nil, invalid(ErrInvalidURL, "path", escapedPath, "must contain source, operation and image path")
+	}
+	p := &ParsedURL{
+		BaseURL:     base,
+		BossToken:   query.Get("bossToken"),
+		escapedPath: escapedPath,
+	}
+	source, err := validateSource(segments[0])
+	if err != nil {
+		return nil, err
+	}
+	p.Source = source
+
+	kind, mode, _ := strings.Cut(segments[1], ":")
+	rest := segments[2:]
+	switch kind {
+	case "cdn":
+		p.Operation = CDN()
+	case "width", "height":
+		n, err := strconv.Atoi(rest[0])
+		if err != nil {
+			return nil, invalid(ErrInvalidURL, kind, rest[0], "not a number")
+		}
+		if kind == "width" {
+			p.Operation = Width(n)
+		} else {
+			p.Operation = Height(n)
+		}
+		rest = rest[1:]
+	case "cover":
+		w, h, err := parseDimensions(rest[0])
+		if err != nil {
+			return nil, err
+		}
+		p.Operation = CoverMode(w, h, mode)
+		rest = rest[1:]
+	default:
+		return nil, invalid(ErrInvalidURL, "operation", segments[1], "unknown operation")
+	}
+	if mode != "" && kind != "cover" {
+		return nil, invalid(ErrInvalidURL, "operation", segments[1], "only cover takes a mode")
+	}
+
+	// Leading key:value segments are options; at least one segment is always
+	// left for the image path.
+	for len(rest) > 1 && optionSegmentRegexp.MatchString(rest[0]) {
+		seg, err := url.PathUnescape(rest[0])
+		if err != nil {
+			return nil, invalid(ErrInvalidURL, "option", rest[0], err.Error())
+		}
+		key, value, _ := strings.Cut(seg, ":")
+		p.Options = append(p.Options, Param(key, strings.Split(value, ",")...))
+		rest = rest[1:]
+	}
+	if len(rest) == 0 || (len(rest) == 1 && rest[0] == "") {
+		return nil, invalid(ErrInvalidURL, "path", escapedPath, "has no image path")
+	}
+	for i, s := range rest {
+		unescaped, err := url.PathUnescape(s)
+		if err != nil {
+			return nil, invalid(ErrInvalidURL, "path", s, err.Error())
+		}
+		rest[i] = unescaped
+	}
+	p.Path = strings.Join(rest, "/")
+	return p, nil
+}
+
+// parseDimensions parses a "WxH" dimensions segment.
+func parseDimensions(s string) (int, int, error) {
+	ws, hs, ok := strings.Cut(s, "x")
+	if !ok {
+		return 0, 0, invalid(ErrInvalidURL, "dimensions", s, "want WxH")
+	}
+	w, errW := strconv.Atoi(ws)
+	h, errH := strconv.Atoi(hs)
+	if errW != nil || errH != nil {
+		return 0, 0, invalid(ErrInvalidURL, "dimensions", s, "want WxH")
+	}
+	return w, h, nil
+}
@@ -0,0 +1,141 @@
+package imageboss
+
+import (
+	"testing"
+)
+
+func TestParseURL(t *testing.T) {
+	p, err := ParseURL("https://img.imageboss.me/mywebsite-images/cover:center/320x240/blur:4/format:auto/examples/my%20photo.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.BaseURL != DefaultBaseURL {
+		t.Errorf("BaseURL = %s; want %s", p.BaseURL, DefaultBaseURL)
+	}
+	if p.Source != "mywebsite-images" {
+		t.Errorf("Source = %s; want mywebsite-images", p.Source)
+	}
+	if p.Operation != CoverMode(320, 240, "center") {
+		t.Errorf("Operation = %+v; want cover:center 320x240", p.Operation)
+	}
+	if len(p.Options) != 2 || p.Options[0].PathSegment() != "blur:4" || p.Options[1].PathSegment() != "format:auto" {
+		t.Errorf("Options = %v; want [blur:4 format:auto]", p.Options)
+	}
+	if p.Path != "examples/my photo.jpg" {
+		t.Errorf("Path = %q; want %q", p.Path, "examples/my photo.jpg")
+	}
+	if p.BossToken != "" {
+		t.Errorf("BossToken = %q; want empty", p.BossToken)
+	}
+}
+
+func TestParseURL_RoundTrip(t *testing.T) {
+	b := MustNewURLBuilder("mywebsite-images")
+	signed := MustNewURLBuilder("mysecureimages", WithSecret("mysecret"))
+	urls := []string{
+		b.CreateURL("examples/02.jpg", CDN()),
+		b.CreateURL("examples/02.jpg", Width(700), Blur(4), FormatAuto()),
+		b.CreateURL("examples/02.jpg", Height(500)),
+		b.CreateURL("examples/02.jpg", Cover(300, 300)),
+		b.CreateURL("a b/c.jpg", CoverMode(320, 320, "face"), DownloadFilename("my-image.png")),
+		signed.CreateURL("01.jpg", Width(500)),
+	}
+	for _, u := range urls {
+		p, err := ParseURL(u)
+		if err != nil {
+			t.Errorf("ParseURL(%s) err = %v", u, err)
+			continue
+		}
+		if got := p.String(); got != u {
+			t.Errorf("round trip:\ngot:  %s\nwant: %s", got, u)
+		}
+	}
+}
+
+func TestParseURL_BossToken(t *testing.T) {
+	b := MustNewURLBuilder("mysecureimages", WithSecret("mysecret"))
+	p, err := ParseURL(b.CreateURL("01.jpg", Width(500)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.BossToken) != 64 {
+		t.Errorf("BossToken = %q; want 64 hex chars", p.BossToken)
+	}
+}
+
+func TestParseURL_BareOption(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	// A bare option segment cannot be told apart from a directory.
+	p, err := ParseURL(b.CreateURL("a.jpg", CDN(), Param("download")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Options) != 0 || p.Path != "download/a.jpg" {
+		t.Errorf("Options = %v, Path = %q; want none, download/a.jpg", p.Options, p.Path)
+	}
+	p, err = ParseURL(b.CreateURL("a.jpg", CDN(), Download()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Options) != 1 || p.Options[0].PathSegment() != "download:1" || p.Path != "a.jpg" {
+		t.Errorf("Options = %v, Path = %q; want download:1, a.jpg", p.Options, p.Path)
+	}
+}
+
+func TestParseURL_Invalid(t *testing.T) {
+	invalid := []string{
+		"",
+		"/mywebsite-images/cdn/02.jpg",
+		"https://img.imageboss.me/mywebsite-images/cdn",
+		"https://img.imageboss.me/mywebsite-images/resize/700/02.jpg",
+		"https://img.imageboss.me/mywebsite-images/width/abc/02.jpg",
+		"https://img.imageboss.me/mywebsite-images/width/700",
+		"https://img.imageboss.me/mywebsite-images/cover/300/02.jpg",
+		"https://img.imageboss.me/mywebsite-images/width:center/700/02.jpg",
+		"https://img.imageboss.me/bad!source/cdn/02.jpg",
+	}
+	for _, u := range invalid {
+		if _, err := ParseURL(u); err == nil {
+			t.Errorf("ParseURL(%q) expected error", u)
+		}
+	}
+}
+
+func TestURLBuilder_ParseURL(t *testing.T) {
+	b := MustNewURLBuilder("mywebsite-images", WithBaseURL("https://cdn.example.com/images/"))
+	u := b.CreateURL("examples/02.jpg", Width(700), FormatAuto())
+	p, err := b.ParseURL(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.BaseURL != "https://cdn.example.com/images" || p.Source != "mywebsite-images" || p.Path != "examples/02.jpg" {
+		t.Errorf("ParseURL(%s) = %+v", u, p)
+	}
+	if got := p.String(); got != u {
+		t.Errorf("round trip:\ngot:  %s\nwant: %s", got, u)
+	}
+	other := MustNewURLBuilder("other", WithBaseURL("https://cdn.example.com/images"))
+	if _, err := other.ParseURL(u); err == nil {
+		t.Error("expected error for URL from another source")
+	}
+	if _, err := b.ParseURL("https://img.imageboss.me/mywebsite-images/cdn/02.jpg"); err == nil {
+		t.Error("expected error for URL with another base URL")
+	}
+}
+
+func TestURLBuilder_ParseURL_Scheme(t *testing.T) {
+	b := MustNewURLBuilder("mywebsite-images")
+	for _, u := range []string{
+		"http://img.imageboss.me/mywebsite-images/width/700/02.jpg",
+		"//img.imageboss.me/mywebsite-images/width/700/02.jpg",
+	} {
+		p, err := b.ParseURL(u)
+		if err != nil {
+			t.Errorf("ParseURL(%s) err = %v", u, err)
+			continue
+		}
+		if got := p.String(); got != u {
+			t.Errorf("round trip:\ngot:  %s\nwant: %s", got, u)
+		}
+	}
+}
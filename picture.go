@@ -0,0 +1,163 @@
+package imageboss
+
+import (
+	"html/template"
+	"strings"
+)
+
+// DefaultSourceFormats are the formats of the <source> elements Picture
+// renders, in order of preference.
+var DefaultSourceFormats = []ImageFormat{AVIF, WebP}
+
+// mimeTypes are the MIME types of the explicit output formats.
+var mimeTypes = map[ImageFormat]string{
+	AVIF: "image/avif",
+	WebP: "image/webp",
+	JPEG: "image/jpeg",
+	PNG:  "image/png",
+}
+
+// WithSourceFormats sets the formats of the <source> elements Picture renders,
+// in order of preference (DefaultSourceFormats if unset). Auto is not allowed.
+func WithSourceFormats(formats ...ImageFormat) ImgOption {
+	return func(c *imgConfig) {
+		c.sourceFormats = append([]ImageFormat(nil), formats...)
+	}
+}
+
+// WithFallbackFormat sets the format of Picture's <img> fallback (JPEG if
+// unset), e.g. PNG for images with transparency. Auto is not allowed.
+func WithFallbackFormat(f ImageFormat) ImgOption {
+	return func(c *imgConfig) {
+		c.fallbackFormat = f
+	}
+}
+
+// Picture renders a <picture> element with a <source> per source format and
+// an <img> fallback, each with its own srcset using an explicit format option,
+// so browsers pick a format by its type attribute rather than by Accept-header
+// negotiation (which some caches, email clients and feed readers break):
+//
+//	<picture>
+//	<source type="image/avif" srcset="…/format:avif/… 1x, …">
+//	<source type="image/webp" srcset="…/format:webp/… 1x, …">
+//	<img src="…/format:jpg/…" srcset="…" alt="…">
+//	</picture>
+//
+// Variants added with WithVariant come first, each with a <source media=…>
+// per source format and one in the fallback format.
+//
+// Any format option in options or the builder's default options is replaced.
+// The <img> is rendered as by Img with opts; sources share its srcset and
+// sizes settings. Invalid attributes and formats are left out; use
+// BuildPicture to get an error for them instead.
+func (b *URLBuilder) Picture(path string, op Operation, options []Option, opts ...ImgOption) template.HTML {
+	picture, _ := b.renderPicture(path, op, options, newImgConfig(opts))
+	return picture
+}
+
+// BuildPicture is like Picture but validates its arguments first, as BuildImg
+// does, and returns a *ValidationError wrapping ErrInvalidOption for an Auto
+// or unknown source or fallback format.
+func (b *URLBuilder) BuildPicture(path string, op Operation, options []Option, opts ...ImgOption) (template.HTML, error) {
+	c := newImgConfig(opts)
+	if err := c.validate(); err != nil {
+		return "", err
+	}
+	if err := validateFormats(append([]ImageFormat{c.fallbackFormat}, c.sourceFormats...)); err != nil {
+		return "", err
+	}
+	b = b.snapshot()
+	if err := validateSrcsetArgs(path, op, options, newSrcsetOptions(b.config().srcsetDefaults, c.srcsetOpts)); err != nil {
+		return "", err
+	}
+	for _, v := range c.variants {
+		if err := v.validate(path, op, options); err != nil {
+			return "", err
+		}
+	}
+	return b.renderPicture(path, op, options, c)
+}
+
+// renderPicture renders the <picture> element. On an error it returns the
+// element as Picture renders it and the first error.
+func (b *URLBuilder) renderPicture(path string, op Operation, options []Option, c imgConfig) (template.HTML, error) {
+	b = b.snapshot()
+	var sb strings.Builder
+	var firstErr error
+	check := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	sb.WriteString("<picture>\n")
+	for _, v := range c.variants {
+		if v.Media.text == "" {
+			continue
+		}
+		vpath, vop, voptions := v.apply(path, op, options)
+		for _, f := range c.sourceFormats {
+			check(b.writeSource(&sb, v.Media.String(), f, vpath, vop, voptions, c))
+		}
+		// The <img> cannot carry the variant, so it gets a source in the
+		// fallback format too.
+		check(b.writeSource(&sb, v.Media.String(), c.fallbackFormat, vpath, vop, voptions, c))
+	}
+	for _, f := range c.sourceFormats {
+		if f != c.fallbackFormat {
+			check(b.writeSource(&sb, "", f, path, op, options, c))
+		}
+	}
+	fallback := options
+	if mimeTypes[c.fallbackFormat] != "" {
+		fallback = withFormat(options, c.fallbackFormat)
+	}
+	check(b.writeImg(&sb, path, op, fallback, c))
+	sb.WriteString("\n</picture>")
+	return template.HTML(sb.String()), firstErr
+}
+
+// writeSource writes a <source> for the image in format f, with media if not
+// empty. Unknown formats are skipped.
+func (b *URLBuilder) writeSource(sb *strings.Builder, media string, f ImageFormat, path string, op Operation, options []Option, c imgConfig) error {
+	if mimeTypes[f] == "" {
+		return nil
+	}
+	srcset, fixed, err := b.imgSrcset(path, op, withFormat(options, f), c)
+	sb.WriteString("<source")
+	if media != "" {
+		writeAttr(sb, "media", media)
+	}
+	writeAttr(sb, "type", mimeTypes[f])
+	c.writeSrcset(sb, srcset, fixed)
+	sb.WriteString(">\n")
+	return err
+}
+
+// withFormat returns options with any format option replaced by Format(f).
+// The explicit option also replaces a default format option (see
+// WithDefaultOptions).
+func withFormat(options []Option, f ImageFormat) []Option {
+	out := make([]Option, 0, len(options)+1)
+	for _, o := range options {
+		if optionKey(o) != "format" {
+			out = append(out, o)
+		}
+	}
+	return append(out, Format(f))
+}
+
+// Picture renders a <picture> element for the request (see
+// URLBuilder.Picture), or "" if Err is not nil. Invalid attributes and formats
+// are recorded and reported by Err.
+func (r *ImageRequest) Picture(opts ...ImgOption) template.HTML {
+	if r.Err() != nil {
+		return ""
+	}
+	picture, err := r.b.BuildPicture(r.path, r.op, r.options, opts...)
+	if err != nil {
+		r.errs = append(r.errs, err)
+		return ""
+	}
+	return picture
+}
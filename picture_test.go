@@ -0,0 +1,61 @@
+package imageboss
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPicture(t *testing.T) {
+	b := MustNewURLBuilder("demo", WithDefaultOptions(FormatAuto()))
+	got := string(b.Picture("a.jpg", Width(300), []Option{Blur(2)},
+		WithAlt("lake"), WithSrcset(WithDensities(1, 2), WithVariableQuality(false))))
+	want := "<picture>\n" +
+		`<source type="image/avif" srcset="https://img.imageboss.me/demo/width/300/blur:2/format:avif/a.jpg 1x, https://img.imageboss.me/demo/width/300/blur:2/format:avif/dpr:2/a.jpg 2x">` + "\n" +
+		`<source type="image/webp" srcset="https://img.imageboss.me/demo/width/300/blur:2/format:webp/a.jpg 1x, https://img.imageboss.me/demo/width/300/blur:2/format:webp/dpr:2/a.jpg 2x">` + "\n" +
+		`<img src="https://img.imageboss.me/demo/width/300/blur:2/format:jpg/a.jpg" srcset="https://img.imageboss.me/demo/width/300/blur:2/format:jpg/a.jpg 1x, https://img.imageboss.me/demo/width/300/blur:2/format:jpg/dpr:2/a.jpg 2x" alt="lake">` + "\n" +
+		"</picture>"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+	if strings.Contains(got, "format:auto") {
+		t.Errorf("default format:auto not replaced:\n%s", got)
+	}
+}
+
+func TestPicture_Fluid(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	sizes := Sizes{}.Default(Px(320))
+	got := string(b.Picture("a.png", CDN(), []Option{Format(WebP)},
+		WithSizes(sizes), WithSourceFormats(WebP), WithFallbackFormat(PNG)))
+	if n := strings.Count(got, "<source"); n != 1 {
+		t.Errorf("got %d sources; want 1:\n%s", n, got)
+	}
+	if n := strings.Count(got, ` sizes="320px"`); n != 2 {
+		t.Errorf("got %d sizes attributes; want 2:\n%s", n, got)
+	}
+	if !strings.Contains(got, `<source type="image/webp" srcset="https://img.imageboss.me/demo/width/320/format:webp/a.png 320w, `) ||
+		!strings.Contains(got, `<img src="https://img.imageboss.me/demo/width/640/format:png/a.png"`) {
+		t.Errorf("unexpected picture:\n%s", got)
+	}
+}
+
+func TestBuildPicture_Invalid(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	for _, opts := range [][]ImgOption{
+		{WithSourceFormats(Auto)},
+		{WithFallbackFormat("gif")},
+		{WithLoading("never")},
+	} {
+		if _, err := b.BuildPicture("a.jpg", Width(100), nil, opts...); !errors.Is(err, ErrInvalidOption) && !errors.Is(err, ErrInvalidAttribute) {
+			t.Errorf("BuildPicture = %v; want an error", err)
+		}
+	}
+	r := b.Image("a.jpg").Width(100)
+	if got := r.Picture(WithSourceFormats(Auto)); got != "" || !errors.Is(r.Err(), ErrInvalidOption) {
+		t.Errorf("ImageRequest.Picture = %q, Err() = %v", got, r.Err())
+	}
+	if got := b.Image("a.jpg").Width(100).Picture(); !strings.HasPrefix(string(got), "<picture>") {
+		t.Errorf("ImageRequest.Picture = %q", got)
+	}
+}
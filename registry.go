@@ -0,0 +1,106 @@
+package imageboss
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Registry holds named URLBuilders, typically one per ImageBoss source, each
+// with its own secret and base URL. It is safe for concurrent use.
+//
+//	reg := imageboss.NewRegistry(imageboss.WithBaseURL("https://img.example.com"))
+//	reg.Register("avatars", "acme-avatars", imageboss.WithSecret(avatarSecret))
+//	url := reg.Source("avatars").CreateURL("u/42.jpg", imageboss.Cover(64, 64))
+type Registry struct {
+	defaults []BuilderOption
+
+	mu       sync.RWMutex
+	builders map[string]*URLBuilder
+}
+
+// NewRegistry returns an empty Registry. defaults are applied to every builder
+// created by Register, before that builder's own options.
+func NewRegistry(defaults ...BuilderOption) *Registry {
+	return &Registry{defaults: defaults, builders: make(map[string]*URLBuilder)}
+}
+
+// Register creates a URLBuilder for source with the registry defaults and
+// options, and stores it under name. It returns an error wrapping
+// ErrDuplicateSource if name is already registered.
+func (r *Registry) Register(name, source string, options ...BuilderOption) (*URLBuilder, error) {
+	opts := append(append([]BuilderOption(nil), r.defaults...), options...)
+	b, err := NewURLBuilder(source, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.Add(name, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Add stores an existing builder under name. It returns an error wrapping
+// ErrDuplicateSource if name is already registered.
+func (r *Registry) Add(name string, b *URLBuilder) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.builders[name]; ok {
+		return fmt.Errorf("%w: %q", ErrDuplicateSource, name)
+	}
+	r.builders[name] = b
+	return nil
+}
+
+// Lookup returns the builder registered under name.
+func (r *Registry) Lookup(name string) (*URLBuilder, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	b, ok := r.builders[name]
+	return b, ok
+}
+
+// Source returns the builder registered under name. It panics if there is
+// none; use Lookup when the name is not known to be registered.
+func (r *Registry) Source(name string) *URLBuilder {
+	b, ok := r.Lookup(name)
+	if !ok {
+		panic(fmt.Errorf("%w: %q", ErrUnknownSource, name))
+	}
+	return b
+}
+
+// Names returns the registered names in sorted order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.builders))
+	for name := range r.builders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ForURL returns the builder whose base URL and source match rawURL, along with
+// the parsed URL. Builders are tried in name order. It returns an error
+// wrapping ErrUnknownSource if no builder matches.
+func (r *Registry) ForURL(rawURL string) (*URLBuilder, *ParsedURL, error) {
+	for _, name := range r.Names() {
+		b, _ := r.Lookup(name)
+		if p, err := b.ParseURL(rawURL); err == nil {
+			return b, p, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("%w: no builder for %s", ErrUnknownSource, rawURL)
+}
+
+// Verify checks the bossToken of rawURL with the builder ForURL finds for it.
+// See URLBuilder.Verify.
+func (r *Registry) Verify(rawURL string) error {
+	b, _, err := r.ForURL(rawURL)
+	if err != nil {
+		return err
+	}
+	return b.Verify(rawURL)
+}
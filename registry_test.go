@@ -0,0 +1,80 @@
+package imageboss
+
+import (
+	"errors"
+	"testing"
+)
+
+func newTestRegistry(t *testing.T) *Registry {
+	t.Helper()
+	reg := NewRegistry(WithBaseURL("https://img.example.com"))
+	if _, err := reg.Register("products", "acme-products"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reg.Register("avatars", "acme-avatars", WithSecret("avatarsecret")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reg.Register("marketing", "acme-marketing", WithBaseURL("https://cdn.example.com/mkt"), WithSecret("mktsecret")); err != nil {
+		t.Fatal(err)
+	}
+	return reg
+}
+
+func TestRegistry_Source(t *testing.T) {
+	reg := newTestRegistry(t)
+	got := reg.Source("products").CreateURL("shoe.jpg", Width(300))
+	if want := "https://img.example.com/acme-products/width/300/shoe.jpg"; got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+	if got, want := reg.Names(), []string{"avatars", "marketing", "products"}; len(got) != len(want) || got[0] != want[0] || got[2] != want[2] {
+		t.Errorf("Names() = %v; want %v", got, want)
+	}
+	if _, ok := reg.Lookup("missing"); ok {
+		t.Error("Lookup(missing) = ok")
+	}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Source(missing) should panic")
+		}
+	}()
+	reg.Source("missing")
+}
+
+func TestRegistry_Register(t *testing.T) {
+	reg := newTestRegistry(t)
+	if _, err := reg.Register("products", "other"); !errors.Is(err, ErrDuplicateSource) {
+		t.Errorf("Register(duplicate) err = %v; want ErrDuplicateSource", err)
+	}
+	if _, err := reg.Register("bad", "bad source"); !errors.Is(err, ErrInvalidSource) {
+		t.Errorf("Register(invalid source) err = %v; want ErrInvalidSource", err)
+	}
+	if err := reg.Add("extra", MustNewURLBuilder("acme-extra")); err != nil {
+		t.Errorf("Add = %v", err)
+	}
+}
+
+func TestRegistry_ForURLAndVerify(t *testing.T) {
+	reg := newTestRegistry(t)
+	avatar := reg.Source("avatars").CreateURL("u/42.jpg", Cover(64, 64))
+	marketing := reg.Source("marketing").CreateURL("hero.jpg", Width(1200))
+
+	b, p, err := reg.ForURL(marketing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b != reg.Source("marketing") || p.Source != "acme-marketing" || p.Path != "hero.jpg" {
+		t.Errorf("ForURL(%s) = %s, %+v", marketing, b.Source(), p)
+	}
+	for _, u := range []string{avatar, marketing} {
+		if err := reg.Verify(u); err != nil {
+			t.Errorf("Verify(%s) = %v", u, err)
+		}
+	}
+	forged := MustNewURLBuilder("acme-avatars", WithBaseURL("https://img.example.com"), WithSecret("wrong")).CreateURL("u/42.jpg", Cover(64, 64))
+	if err := reg.Verify(forged); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("Verify(forged) = %v; want ErrBadSignature", err)
+	}
+	if _, _, err := reg.ForURL("https://img.example.com/unknown/cdn/a.jpg"); !errors.Is(err, ErrUnknownSource) {
+		t.Errorf("ForURL(unknown source) err = %v; want ErrUnknownSource", err)
+	}
+}
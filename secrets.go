@@ -0,0 +1,194 @@
+package imageboss
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRotationGrace is how long WithSecretProvider keeps accepting a secret
+// in Verify after the provider starts returning a new one.
+const DefaultRotationGrace = 24 * time.Hour
+
+// SecretProvider supplies the signing secret. It is called every time a URL is
+// signed or verified, so a new secret takes effect without rebuilding builders.
+type SecretProvider interface {
+	Secret() (string, error)
+}
+
+// SecretFunc adapts a function to SecretProvider.
+type SecretFunc func() (string, error)
+
+// Secret returns f().
+func (f SecretFunc) Secret() (string, error) {
+	return f()
+}
+
+// EnvSecret returns a SecretProvider that reads environment variable name.
+// It returns an error wrapping ErrNoSecret if the variable is unset or empty.
+func EnvSecret(name string) SecretProvider {
+	return SecretFunc(func() (string, error) {
+		secret := os.Getenv(name)
+		if secret == "" {
+			return "", fmt.Errorf("%w: environment variable %s is empty", ErrNoSecret, name)
+		}
+		return secret, nil
+	})
+}
+
+// FileSecret returns a SecretProvider that reads the secret from the file at
+// path, ignoring surrounding whitespace. The file is re-read when its size or
+// modification time changes, so replacing it rotates the secret.
+func FileSecret(path string) SecretProvider {
+	return &fileSecret{path: path}
+}
+
+type fileSecret struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	secret  string
+}
+
+func (f *fileSecret) Secret() (string, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return "", fmt.Errorf("imageboss: reading secret: %w", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.secret != "" && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.secret, nil
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return "", fmt.Errorf("imageboss: reading secret: %w", err)
+	}
+	secret := strings.TrimSpace(string(data))
+	if secret == "" {
+		return "", fmt.Errorf("%w: secret file %s is empty", ErrNoSecret, f.path)
+	}
+	f.secret, f.modTime, f.size = secret, info.ModTime(), info.Size()
+	return secret, nil
+}
+
+// SecretSigner is an HMAC SHA-256 Signer whose secret comes from a
+// SecretProvider. When the provider returns a new secret, the previous one is
+// still accepted by Verify for the grace period, so links signed just before a
+// rotation keep validating. A SecretSigner is safe for concurrent use.
+type SecretSigner struct {
+	provider SecretProvider
+	grace    time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	current string
+	retired []retiredSecret
+}
+
+type retiredSecret struct {
+	secret  string
+	expires time.Time
+}
+
+// NewSecretSigner returns a SecretSigner reading secrets from p and accepting
+// replaced secrets for grace.
+func NewSecretSigner(p SecretProvider, grace time.Duration) *SecretSigner {
+	return &SecretSigner{provider: p, grace: grace, now: time.Now}
+}
+
+// secret returns the provider's current secret and the retired secrets still
+// within their grace period, retiring the previous secret if it changed.
+func (s *SecretSigner) secret() (string, []string, error) {
+	secret, err := s.provider.Secret()
+	if err != nil {
+		return "", nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if s.current != "" && s.current != secret {
+		s.retired = append(s.retired, retiredSecret{secret: s.current, expires: now.Add(s.grace)})
+	}
+	s.current = secret
+	var previous []string
+	kept := s.retired[:0]
+	for _, r := range s.retired {
+		if now.Before(r.expires) && r.secret != secret {
+			kept = append(kept, r)
+			previous = append(previous, r.secret)
+		}
+	}
+	s.retired = kept
+	return secret, previous, nil
+}
+
+// Sign returns the HMAC SHA-256 of path keyed with the current secret.
+func (s *SecretSigner) Sign(path string) (string, error) {
+	secret, _, err := s.secret()
+	if err != nil {
+		return "", err
+	}
+	return signPath(secret, path), nil
+}
+
+// Verify checks token against the current secret and any secret retired less
+// than the grace period ago.
+func (s *SecretSigner) Verify(path, token string) error {
+	secret, previous, err := s.secret()
+	if err != nil {
+		return err
+	}
+	return verifyAny(append([]string{secret}, previous...), path, token)
+}
+
+// verifyAny is verifyPath that accepts a token signed with any of secrets.
+func verifyAny(secrets []string, path, token string) error {
+	err := ErrBadSignature
+	for _, secret := range secrets {
+		if err = verifyPath(secret, path, token); !errors.Is(err, ErrBadSignature) {
+			return err
+		}
+	}
+	return err
+}
+
+// WithSecretProvider signs URLs with the secret returned by p at sign time
+// (see SecretSigner). Secrets replaced by p keep verifying for
+// DefaultRotationGrace; use WithSigner(NewSecretSigner(p, grace)) for another
+// grace period.
+func WithSecretProvider(p SecretProvider) BuilderOption {
+	return WithSigner(NewSecretSigner(p, DefaultRotationGrace))
+}
+
+// WithPreviousSecrets makes URLBuilder.Verify also accept tokens signed with
+// any of secrets until the given time, e.g. the secret in use before a
+// rotation while old links expire from caches. Unlike the grace period of a
+// SecretSigner, it survives restarts. It does not affect signing. Repeated
+// WithPreviousSecrets calls accumulate, each with its own expiry.
+func WithPreviousSecrets(until time.Time, secrets ...string) BuilderOption {
+	return func(c *builderConfig) {
+		previous := make([]retiredSecret, 0, len(c.previousSecrets)+len(secrets))
+		previous = append(previous, c.previousSecrets...)
+		for _, secret := range secrets {
+			previous = append(previous, retiredSecret{secret: secret, expires: until})
+		}
+		c.previousSecrets = previous
+	}
+}
+
+// unexpiredSecrets returns the secrets of previous that expire after now.
+func unexpiredSecrets(previous []retiredSecret, now time.Time) []string {
+	var secrets []string
+	for _, r := range previous {
+		if now.Before(r.expires) {
+			secrets = append(secrets, r.secret)
+		}
+	}
+	return secrets
+}
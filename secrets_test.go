@@ -0,0 +1,99 @@
+package imageboss
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEnvSecret(t *testing.T) {
+	t.Setenv("IMAGEBOSS_TEST_SECRET", "envsecret")
+	b := MustNewURLBuilder("demo", WithSecretProvider(EnvSecret("IMAGEBOSS_TEST_SECRET")))
+	u := b.CreateURL("a.jpg", Width(100))
+	if err := VerifyURL(u, "envsecret"); err != nil {
+		t.Errorf("VerifyURL = %v", err)
+	}
+	t.Setenv("IMAGEBOSS_TEST_SECRET", "")
+	if _, err := b.BuildURL("a.jpg", Width(100)); !errors.Is(err, ErrNoSecret) {
+		t.Errorf("BuildURL with empty env secret err = %v; want ErrNoSecret", err)
+	}
+}
+
+func TestFileSecret(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte("first\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p := FileSecret(path)
+	if s, err := p.Secret(); err != nil || s != "first" {
+		t.Fatalf("Secret() = %q, %v; want first", s, err)
+	}
+	if err := os.WriteFile(path, []byte("second-secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := p.Secret(); err != nil || s != "second-secret" {
+		t.Errorf("Secret() after rewrite = %q, %v; want second-secret", s, err)
+	}
+	if _, err := FileSecret(filepath.Join(t.TempDir(), "missing")).Secret(); err == nil {
+		t.Error("expected error for missing secret file")
+	}
+}
+
+func TestSecretSigner_Rotation(t *testing.T) {
+	secret := "old"
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	signer := NewSecretSigner(SecretFunc(func() (string, error) { return secret, nil }), time.Hour)
+	signer.now = func() time.Time { return now }
+	b := MustNewURLBuilder("demo", WithSigner(signer))
+
+	oldURL := b.CreateURL("a.jpg", Width(100))
+	secret = "new"
+	newURL := b.CreateURL("a.jpg", Width(100))
+	if oldURL == newURL {
+		t.Fatal("rotated secret should change the token")
+	}
+	if err := VerifyURL(newURL, "new"); err != nil {
+		t.Errorf("new URL not signed with new secret: %v", err)
+	}
+	for _, u := range []string{oldURL, newURL} {
+		if err := b.Verify(u); err != nil {
+			t.Errorf("Verify(%s) within grace = %v", u, err)
+		}
+	}
+
+	now = now.Add(2 * time.Hour)
+	if err := b.Verify(oldURL); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("Verify(old URL) after grace = %v; want ErrBadSignature", err)
+	}
+	if err := b.Verify(newURL); err != nil {
+		t.Errorf("Verify(new URL) after grace = %v", err)
+	}
+}
+
+func TestWithPreviousSecrets(t *testing.T) {
+	oldURL := MustNewURLBuilder("demo", WithSecret("old")).CreateURL("a.jpg", CDN())
+	b := MustNewURLBuilder("demo", WithSecret("new"), WithPreviousSecrets(time.Now().Add(time.Hour), "older", "old"))
+	if err := b.Verify(oldURL); err != nil {
+		t.Errorf("Verify(URL signed with previous secret) = %v", err)
+	}
+	if err := MustNewURLBuilder("demo", WithSecret("new")).Verify(oldURL); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("Verify without previous secrets = %v; want ErrBadSignature", err)
+	}
+	other := MustNewURLBuilder("demo", WithSecret("other")).CreateURL("a.jpg", CDN())
+	if err := b.Verify(other); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("Verify(unknown secret) = %v; want ErrBadSignature", err)
+	}
+
+	expired := MustNewURLBuilder("demo", WithSecret("new"),
+		WithPreviousSecrets(time.Now().Add(-time.Minute), "old"),
+		WithPreviousSecrets(time.Now().Add(time.Hour), "older"))
+	if err := expired.Verify(oldURL); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("Verify(URL signed with expired secret) = %v; want ErrBadSignature", err)
+	}
+	olderURL := MustNewURLBuilder("demo", WithSecret("older")).CreateURL("a.jpg", CDN())
+	if err := expired.Verify(olderURL); err != nil {
+		t.Errorf("Verify(URL signed with unexpired secret) = %v", err)
+	}
+}
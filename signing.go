@@ -3,9 +3,50 @@ package imageboss
 import (
 	"crypto/hmac"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 )
 
+// Signer produces the bossToken for a signed URL. Sign receives the path that
+// ImageBoss verifies, "/<source>/<operation>/.../<image path>", and returns the
+// token appended as ?bossToken=. See https://imageboss.me/docs/security.
+//
+// The default Signer, used by WithSecret, is HMACSigner. Implement Signer to
+// sign through an external key service or a signing sidecar. A Signer may also
+// implement Verify(path, token string) error to check tokens itself;
+// URLBuilder.Verify otherwise re-signs the path and compares the tokens in
+// constant time.
+type Signer interface {
+	Sign(path string) (string, error)
+}
+
+// tokenVerifier is implemented by Signers that check tokens themselves.
+type tokenVerifier interface {
+	Verify(path, token string) error
+}
+
+// HMACSigner returns the Signer ImageBoss expects: the hex-encoded HMAC SHA-256
+// of the path, keyed with the source secret.
+func HMACSigner(secret string) Signer {
+	return hmacSigner{secret: secret}
+}
+
+type hmacSigner struct {
+	secret string
+}
+
+func (s hmacSigner) Sign(path string) (string, error) {
+	return signPath(s.secret, path), nil
+}
+
+func (s hmacSigner) Verify(path, token string) error {
+	return verifyPath(s.secret, path, token)
+}
+
 // signPath returns the HMAC SHA-256 hex digest of path using secret, as required by
 // ImageBoss signed URLs. See https://imageboss.me/docs/security.
 func signPath(secret, path string) string {
@@ -13,3 +54,88 @@ func signPath(secret, path string) string {
 	mac.Write([]byte(path))
 	return hex.EncodeToString(mac.Sum(nil))
 }
+
+// verifyPath checks token against the HMAC SHA-256 of path using secret.
+// The digests are compared in constant time.
+func verifyPath(secret, path, token string) error {
+	if token == "" {
+		return ErrMissingToken
+	}
+	got, err := hex.DecodeString(token)
+	if err != nil {
+		return fmt.Errorf("%w: not hex", ErrMalformedToken)
+	}
+	if len(got) != sha256.Size {
+		return fmt.Errorf("%w: %d bytes, want %d", ErrMalformedToken, len(got), sha256.Size)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(path))
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// verifyWithSigner checks token using s.Verify if s implements it, otherwise by
+// re-signing path and comparing the tokens in constant time.
+func verifyWithSigner(s Signer, path, token string) error {
+	if v, ok := s.(tokenVerifier); ok {
+		return v.Verify(path, token)
+	}
+	if token == "" {
+		return ErrMissingToken
+	}
+	want, err := s.Sign(path)
+	if err != nil {
+		return fmt.Errorf("imageboss: signing %s: %w", path, err)
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(want)) != 1 {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// signingPath returns the path signed for p: /source/operation/.../path. For a
+// parsed URL it is the path exactly as escaped in that URL, so tokens signed by
+// clients that escape differently from CreateURL still verify.
+func (p *ParsedURL) signingPath() string {
+	if p.escapedPath != "" {
+		return "/" + p.escapedPath
+	}
+	return "/" + strings.Join(urlSegments(p.Source, p.Operation, p.Options, sanitizePath(p.Path)), "/")
+}
+
+// VerifyURL checks the bossToken of a signed ImageBoss URL against secret.
+// It returns nil if the token is valid, or ErrMissingToken, ErrMalformedToken or
+// ErrBadSignature (possibly wrapped). Like ParseURL, the base URL is taken to be
+// the scheme and host; use URLBuilder.Verify for base URLs with a path prefix.
+func VerifyURL(rawURL, secret string) error {
+	p, err := ParseURL(rawURL)
+	if err != nil {
+		return err
+	}
+	return verifyPath(secret, p.signingPath(), p.BossToken)
+}
+
+// Verify checks the bossToken of a URL created by this builder (or one with the
+// same base URL, source and signer). Tokens signed with a secret passed to
+// WithPreviousSecrets are accepted too until it expires. It returns ErrNoSecret
+// if the builder has no secret or Signer; otherwise see VerifyURL.
+func (b *URLBuilder) Verify(rawURL string) error {
+	c := b.config()
+	if c.signer == nil {
+		return ErrNoSecret
+	}
+	p, err := c.parseURL(rawURL)
+	if err != nil {
+		return err
+	}
+	path := p.signingPath()
+	err = verifyWithSigner(c.signer, path, p.BossToken)
+	if errors.Is(err, ErrBadSignature) {
+		if previous := unexpiredSecrets(c.previousSecrets, time.Now()); len(previous) > 0 {
+			return verifyAny(previous, path, p.BossToken)
+		}
+	}
+	return err
+}
@@ -2,6 +2,9 @@ package imageboss
 
 import (
 	"encoding/hex"
+	"errors"
+	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -28,3 +31,202 @@ func TestSignPath(t *testing.T) {
 		t.Error("signPath: different secret should yield different token")
 	}
 }
+
+func TestVerifyURL(t *testing.T) {
+	b := MustNewURLBuilder("mysecureimages", WithSecret("mysecret"))
+	u := b.CreateURL("photos/01.jpg", Width(500), Blur(4))
+	if err := VerifyURL(u, "mysecret"); err != nil {
+		t.Errorf("VerifyURL(valid) = %v", err)
+	}
+	if err := b.Verify(u); err != nil {
+		t.Errorf("Verify(valid) = %v", err)
+	}
+
+	unsigned := MustNewURLBuilder("mysecureimages").CreateURL("photos/01.jpg", Width(500))
+	token := u[strings.Index(u, "bossToken=")+len("bossToken="):]
+	tests := []struct {
+		name string
+		url  string
+		want error
+	}{
+		{"missing", unsigned, ErrMissingToken},
+		{"not hex", unsigned + "?bossToken=zz" + token[2:], ErrMalformedToken},
+		{"short", unsigned + "?bossToken=" + token[:32], ErrMalformedToken},
+		{"tampered path", strings.Replace(u, "width/500", "width/501", 1), ErrBadSignature},
+		{"tampered token", unsigned + "?bossToken=" + strings.Repeat("0", 64), ErrBadSignature},
+	}
+	for _, tt := range tests {
+		if err := b.Verify(tt.url); !errors.Is(err, tt.want) {
+			t.Errorf("%s: Verify = %v; want %v", tt.name, err, tt.want)
+		}
+	}
+	if err := VerifyURL(u, "othersecret"); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("VerifyURL(wrong secret) = %v; want ErrBadSignature", err)
+	}
+	if err := MustNewURLBuilder("mysecureimages").Verify(u); !errors.Is(err, ErrNoSecret) {
+		t.Errorf("Verify without secret = %v; want ErrNoSecret", err)
+	}
+}
+
+func TestVerify_BaseURLPrefix(t *testing.T) {
+	b := MustNewURLBuilder("mysecureimages", WithBaseURL("https://cdn.example.com/img"), WithSecret("mysecret"))
+	u := b.CreateURL("01.jpg", Cover(300, 200))
+	if err := b.Verify(u); err != nil {
+		t.Errorf("Verify = %v", err)
+	}
+}
+
+func TestVerify_EscapedPath(t *testing.T) {
+	// Signed over the path as written, not as CreateURL would escape it (%28, %29).
+	path := "/mysecureimages/width/300/a(1).jpg"
+	u := "https://img.imageboss.me" + path + "?bossToken=" + signPath("mysecret", path)
+	if err := VerifyURL(u, "mysecret"); err != nil {
+		t.Errorf("VerifyURL = %v", err)
+	}
+	b := MustNewURLBuilder("mysecureimages", WithSecret("mysecret"))
+	if err := b.Verify(u); err != nil {
+		t.Errorf("Verify = %v", err)
+	}
+	if err := b.Verify(b.CreateURL("a(1).jpg", Width(300))); err != nil {
+		t.Errorf("Verify(CreateURL) = %v", err)
+	}
+}
+
+// fakeSigner returns a deterministic token, or err if set.
+type fakeSigner struct {
+	err error
+}
+
+func (s fakeSigner) Sign(path string) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	return "signed" + strings.ReplaceAll(path, "/", "-"), nil
+}
+
+// countingSigner counts Sign calls.
+type countingSigner struct {
+	calls int
+}
+
+func (s *countingSigner) Sign(path string) (string, error) {
+	s.calls++
+	return "signed", nil
+}
+
+// TestBuild_SignerCalls checks that Build* functions sign each URL once, as
+// their non-validating counterparts do.
+func TestBuild_SignerCalls(t *testing.T) {
+	s := &countingSigner{}
+	b := MustNewURLBuilder("demo", WithSigner(s))
+	calls := func(f func()) int {
+		s.calls = 0
+		f()
+		return s.calls
+	}
+	tests := []struct {
+		name          string
+		create, build func()
+	}{
+		{"Img", func() { b.Img("a.jpg", Width(100), nil) }, func() { b.BuildImg("a.jpg", Width(100), nil) }},
+		{"Picture", func() { b.Picture("a.jpg", Width(100), nil) }, func() { b.BuildPicture("a.jpg", Width(100), nil) }},
+		{"ImageSet", func() { b.ImageSet("a.jpg", Width(100), nil, nil) }, func() { b.BuildImageSet("a.jpg", Width(100), nil, nil) }},
+		{"BackgroundCSS", func() { b.BackgroundCSS(".a", "a.jpg", CDN(), nil, nil) }, func() { b.BuildBackgroundCSS(".a", "a.jpg", CDN(), nil, nil) }},
+	}
+	for _, tt := range tests {
+		if create, build := calls(tt.create), calls(tt.build); create != build {
+			t.Errorf("%s: Build signs %d URLs; want %d", tt.name, build, create)
+		}
+	}
+
+	// ArtDirection has no non-validating form; it should sign only the URLs it renders.
+	var pic *PictureData
+	breakpoints := []Breakpoint{{Media: MediaMinWidth(800), Operation: Width(800)}, {Operation: CDN(), MinWidth: 100, MaxWidth: 400}}
+	n := calls(func() { pic, _ = b.ArtDirection("a.jpg", breakpoints) })
+	if want := strings.Count(string(pic.HTML()), "bossToken="); n != want {
+		t.Errorf("ArtDirection signs %d URLs; want %d", n, want)
+	}
+
+	// BackgroundCSS signs each distinct URL once; the url() fallback reuses the
+	// 1x image-set() entry when there are no formats.
+	urlRegexp := regexp.MustCompile(`url\("([^"]+)"\)`)
+	for _, formats := range [][]ImageFormat{nil, {WebP, JPEG}} {
+		var css string
+		n := calls(func() {
+			css = b.BackgroundCSS(".a", "a.jpg", CDN(), nil, formats, WithMinWidth(300), WithMaxWidth(800))
+		})
+		urls := map[string]bool{}
+		for _, m := range urlRegexp.FindAllStringSubmatch(css, -1) {
+			urls[m[1]] = true
+		}
+		if n != len(urls) {
+			t.Errorf("BackgroundCSS(formats %v) signs %d URLs; want %d", formats, n, len(urls))
+		}
+	}
+}
+
+func TestWithSigner(t *testing.T) {
+	b := MustNewURLBuilder("demo", WithSigner(fakeSigner{}))
+	got := b.CreateURL("a.jpg", Width(100))
+	want := "https://img.imageboss.me/demo/width/100/a.jpg?bossToken=signed-demo-width-100-a.jpg"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+	if err := b.Verify(got); err != nil {
+		t.Errorf("Verify = %v", err)
+	}
+	if err := b.Verify(strings.Replace(got, "width/100", "width/200", 1)); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("Verify(tampered) = %v; want ErrBadSignature", err)
+	}
+
+	// WithSecret and HMACSigner are interchangeable.
+	hmacURL := MustNewURLBuilder("demo", WithSigner(HMACSigner("mysecret"))).CreateURL("a.jpg", Width(100))
+	if secretURL := MustNewURLBuilder("demo", WithSecret("mysecret")).CreateURL("a.jpg", Width(100)); hmacURL != secretURL {
+		t.Errorf("HMACSigner URL %s != WithSecret URL %s", hmacURL, secretURL)
+	}
+}
+
+func TestWithSigner_Error(t *testing.T) {
+	signErr := errors.New("key service unavailable")
+	b := MustNewURLBuilder("demo", WithSigner(fakeSigner{err: signErr}))
+	if got, want := b.CreateURL("a.jpg", CDN()), "https://img.imageboss.me/demo/cdn/a.jpg"; got != want {
+		t.Errorf("CreateURL with failing signer = %s; want unsigned %s", got, want)
+	}
+	if _, err := b.BuildURL("a.jpg", CDN()); !errors.Is(err, signErr) {
+		t.Errorf("BuildURL err = %v; want %v", err, signErr)
+	}
+	if MustNewURLBuilder("demo", WithSecret("s"), WithSecret("")).CreateURL("a.jpg", CDN()) != "https://img.imageboss.me/demo/cdn/a.jpg" {
+		t.Error("WithSecret(\"\") should turn signing off")
+	}
+}
+
+func TestWithSigner_ErrorBuild(t *testing.T) {
+	signErr := errors.New("key service unavailable")
+	b := MustNewURLBuilder("demo", WithSigner(fakeSigner{err: signErr}))
+	breakpoints := []Breakpoint{{Media: MediaMinWidth(800), Operation: Width(800)}, {Operation: Width(400)}}
+	builds := map[string]func() error{
+		"BuildSrcset fixed": func() error { _, err := b.BuildSrcset("a.jpg", Width(100), nil); return err },
+		"BuildSrcset fluid": func() error { _, err := b.BuildSrcset("a.jpg", CDN(), nil); return err },
+		"BuildImg":          func() error { _, err := b.BuildImg("a.jpg", Width(100), nil); return err },
+		"BuildPicture":      func() error { _, err := b.BuildPicture("a.jpg", Width(100), nil); return err },
+		"ArtDirection":      func() error { _, err := b.ArtDirection("a.jpg", breakpoints); return err },
+		"BuildImageSet":     func() error { _, err := b.BuildImageSet("a.jpg", Width(100), nil, nil); return err },
+		"BuildBackgroundCSS": func() error {
+			_, err := b.BuildBackgroundCSS(".a", "a.jpg", CDN(), nil, nil)
+			return err
+		},
+	}
+	for name, build := range builds {
+		if err := build(); !errors.Is(err, signErr) {
+			t.Errorf("%s err = %v; want %v", name, err, signErr)
+		}
+	}
+	if got := b.CreateSrcset("a.jpg", Width(100), nil); !strings.HasPrefix(got, "https://img.imageboss.me/demo/width/100/") {
+		t.Errorf("CreateSrcset with failing signer = %s; want unsigned URLs", got)
+	}
+
+	r := b.Image("a.jpg").Width(100)
+	if got := r.URL(); got != "" || !errors.Is(r.Err(), signErr) {
+		t.Errorf("ImageRequest.URL = %q, Err() = %v; want \"\", %v", got, r.Err(), signErr)
+	}
+}
@@ -0,0 +1,202 @@
+package imageboss
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Viewport widths in CSS pixels assumed by Sizes.Widths unless set with
+// Sizes.Viewport.
+const (
+	DefaultMinViewport = 320
+	DefaultMaxViewport = 2560
+)
+
+// Length is a CSS length in a sizes attribute. Create one with Px or VW.
+type Length struct {
+	value float64
+	unit  string // "px" or "vw"
+}
+
+// Px returns a length of n CSS pixels.
+func Px(n float64) Length {
+	return Length{value: n, unit: "px"}
+}
+
+// VW returns a length of n percent of the viewport width.
+func VW(n float64) Length {
+	return Length{value: n, unit: "vw"}
+}
+
+func (l Length) String() string {
+	return strconv.FormatFloat(l.value, 'f', -1, 64) + l.unit
+}
+
+// at returns the length in CSS pixels for a viewport vw CSS pixels wide.
+func (l Length) at(vw int) float64 {
+	if l.unit == "vw" {
+		return l.value * float64(vw) / 100
+	}
+	return l.value
+}
+
+func (l Length) validate() error {
+	if !(l.value > 0) || math.IsInf(l.value, 0) || (l.unit != "px" && l.unit != "vw") {
+		return invalid(ErrInvalidSizes, "length", l.String(), "must be a positive px or vw length")
+	}
+	return nil
+}
+
+// MediaCondition is the media condition of a sizes entry. Create one with
+// MediaMaxWidth, MediaMinWidth or MediaQuery.
+type MediaCondition struct {
+	text     string
+	min, max int // viewport range in CSS pixels it matches; 0 means unbounded
+}
+
+// MediaMaxWidth returns the condition "(max-width: Npx)".
+func MediaMaxWidth(px int) MediaCondition {
+	return MediaCondition{text: "(max-width: " + strconv.Itoa(px) + "px)", max: px}
+}
+
+// MediaMinWidth returns the condition "(min-width: Npx)".
+func MediaMinWidth(px int) MediaCondition {
+	return MediaCondition{text: "(min-width: " + strconv.Itoa(px) + "px)", min: px}
+}
+
+// MediaQuery returns a condition written as-is, e.g.
+// "(orientation: landscape)". Sizes.Widths assumes it can match at any
+// viewport width.
+func MediaQuery(query string) MediaCondition {
+	return MediaCondition{text: strings.TrimSpace(query)}
+}
+
+func (c MediaCondition) String() string {
+	return c.text
+}
+
+// Sizes builds a sizes attribute: lengths for media conditions, tried in
+// order, and a default length. The zero value is "100vw" for every viewport.
+//
+//	sizes := imageboss.Sizes{}.
+//		When(imageboss.MediaMaxWidth(640), imageboss.VW(100)).
+//		When(imageboss.MediaMaxWidth(1024), imageboss.VW(50)).
+//		Default(imageboss.Px(320))
+//	sizes.String() // "(max-width: 640px) 100vw, (max-width: 1024px) 50vw, 320px"
+//
+// Sizes is a value; When and Default return modified copies.
+type Sizes struct {
+	entries     []sizesEntry
+	def         *Length
+	minViewport int
+	maxViewport int
+}
+
+type sizesEntry struct {
+	cond   MediaCondition
+	length Length
+}
+
+// When adds an entry used when cond matches and no earlier entry did.
+func (s Sizes) When(cond MediaCondition, length Length) Sizes {
+	s.entries = append(append([]sizesEntry(nil), s.entries...), sizesEntry{cond, length})
+	return s
+}
+
+// Default sets the length used when no condition matches (100vw if unset).
+func (s Sizes) Default(length Length) Sizes {
+	s.def = &length
+	return s
+}
+
+// Viewport sets the range of viewport widths, in CSS pixels, that Widths
+// plans for (DefaultMinViewport to DefaultMaxViewport if unset).
+func (s Sizes) Viewport(minWidth, maxWidth int) Sizes {
+	s.minViewport, s.maxViewport = minWidth, maxWidth
+	return s
+}
+
+// String returns the sizes attribute value.
+func (s Sizes) String() string {
+	parts := make([]string, 0, len(s.entries)+1)
+	for _, e := range s.entries {
+		parts = append(parts, e.cond.String()+" "+e.length.String())
+	}
+	return strings.Join(append(parts, s.defaultLength().String()), ", ")
+}
+
+// validate checks every length of s.
+func (s Sizes) validate() error {
+	for _, e := range s.entries {
+		if err := e.length.validate(); err != nil {
+			return err
+		}
+	}
+	return s.defaultLength().validate()
+}
+
+func (s Sizes) defaultLength() Length {
+	if s.def != nil {
+		return *s.def
+	}
+	return VW(100)
+}
+
+// Widths returns the widths for a srcset matching s: from the smallest
+// rendered size at minDPR to the largest at maxDPR, spaced by tolerance as in
+// TargetWidths and capped at MaxDimension. Pass them to CreateSrcsetFromWidths:
+//
+//	widths, err := sizes.Widths(1, 2, 0.08)
+//	srcset := b.CreateSrcsetFromWidths(path, imageboss.CDN(), nil, widths)
+//
+// It returns a *ValidationError wrapping ErrInvalidSizes for a non-positive
+// length, DPR or viewport range, or ErrInvalidTolerance for tolerance.
+func (s Sizes) Widths(minDPR, maxDPR, tolerance float64) ([]int, error) {
+	if !(minDPR > 0) || maxDPR < minDPR || math.IsInf(maxDPR, 0) {
+		return nil, invalid(ErrInvalidSizes, "dpr", [2]float64{minDPR, maxDPR}, "want 0 < minDPR <= maxDPR")
+	}
+	lo, hi := s.minViewport, s.maxViewport
+	if lo == 0 && hi == 0 {
+		lo, hi = DefaultMinViewport, DefaultMaxViewport
+	}
+	if lo <= 0 || hi < lo {
+		return nil, invalid(ErrInvalidSizes, "viewport", [2]int{lo, hi}, "want 0 < min <= max")
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+
+	// Walk the entries in order, narrowing the viewport range not yet matched.
+	minCSS, maxCSS := math.Inf(1), 0.0
+	render := func(l Length, from, to int) {
+		minCSS = math.Min(minCSS, l.at(from))
+		maxCSS = math.Max(maxCSS, l.at(to))
+	}
+	for _, e := range s.entries {
+		from, to := lo, hi
+		if e.cond.min > 0 {
+			from = max(from, e.cond.min)
+		}
+		if e.cond.max > 0 {
+			to = min(to, e.cond.max)
+		}
+		if from > to {
+			continue
+		}
+		render(e.length, from, to)
+		switch {
+		case e.cond.max > 0 && e.cond.min == 0:
+			lo = max(lo, e.cond.max+1)
+		case e.cond.min > 0 && e.cond.max == 0:
+			hi = min(hi, e.cond.min-1)
+		}
+	}
+	if lo <= hi {
+		render(s.defaultLength(), lo, hi)
+	}
+
+	minW := int(math.Ceil(minCSS * minDPR))
+	maxW := min(int(math.Ceil(maxCSS*maxDPR)), MaxDimension)
+	return ComputeTargetWidths(min(minW, maxW), maxW, tolerance)
+}
@@ -0,0 +1,94 @@
+package imageboss
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSizes_String(t *testing.T) {
+	tests := []struct {
+		sizes Sizes
+		want  string
+	}{
+		{Sizes{}, "100vw"},
+		{Sizes{}.Default(Px(320)), "320px"},
+		{
+			Sizes{}.When(MediaMaxWidth(640), VW(100)).When(MediaMinWidth(1024), Px(320)).Default(VW(50)),
+			"(max-width: 640px) 100vw, (min-width: 1024px) 320px, 50vw",
+		},
+		{Sizes{}.When(MediaQuery(" (orientation: portrait) "), VW(33.3)), "(orientation: portrait) 33.3vw, 100vw"},
+	}
+	for _, tt := range tests {
+		if got := tt.sizes.String(); got != tt.want {
+			t.Errorf("got %q; want %q", got, tt.want)
+		}
+	}
+
+	base := Sizes{}.When(MediaMaxWidth(640), VW(100))
+	_ = base.When(MediaMaxWidth(1024), VW(50))
+	if got := base.String(); got != "(max-width: 640px) 100vw, 100vw" {
+		t.Errorf("When modified the receiver: %q", got)
+	}
+}
+
+func TestSizes_Widths(t *testing.T) {
+	tests := []struct {
+		name     string
+		sizes    Sizes
+		min, max int
+	}{
+		{"fixed thumbnail", Sizes{}.Default(Px(320)), 320, 640},
+		{"full width", Sizes{}, 320, 5120},
+		{"small viewport", Sizes{}.Viewport(320, 1280), 320, 2560},
+		{"max-width then default", Sizes{}.When(MediaMaxWidth(640), VW(100)).Default(Px(400)), 320, 1280},
+		{"min-width narrows default", Sizes{}.When(MediaMinWidth(1024), Px(300)).Default(VW(50)), 160, 1023},
+		{"unreachable entry", Sizes{}.When(MediaMaxWidth(100), Px(2000)).Default(Px(200)), 200, 400},
+		{"capped", Sizes{}.Default(Px(6000)), 6000, MaxDimension},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			widths, err := tt.sizes.Widths(1, 2, 0.08)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := widths[0]; got != tt.min {
+				t.Errorf("min width = %d; want %d", got, tt.min)
+			}
+			if got := widths[len(widths)-1]; got != tt.max {
+				t.Errorf("max width = %d; want %d", got, tt.max)
+			}
+		})
+	}
+
+	thumb, _ := Sizes{}.Default(Px(320)).Widths(1, 2, 0.08)
+	if len(thumb) >= len(DefaultWidths) {
+		t.Errorf("thumbnail widths = %v; want far fewer than DefaultWidths", thumb)
+	}
+	b := MustNewURLBuilder("demo")
+	got := b.CreateSrcsetFromWidths("a.jpg", CDN(), nil, thumb)
+	want := b.CreateSrcset("a.jpg", CDN(), nil, WithMinWidth(320), WithMaxWidth(640))
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestSizes_WidthsInvalid(t *testing.T) {
+	tests := []struct {
+		sizes          Sizes
+		minDPR, maxDPR float64
+		tolerance      float64
+		want           error
+	}{
+		{Sizes{}, 0, 2, 0.08, ErrInvalidSizes},
+		{Sizes{}, 2, 1, 0.08, ErrInvalidSizes},
+		{Sizes{}.Default(Px(0)), 1, 2, 0.08, ErrInvalidSizes},
+		{Sizes{}.When(MediaMaxWidth(640), VW(-5)), 1, 2, 0.08, ErrInvalidSizes},
+		{Sizes{}.Viewport(800, 400), 1, 2, 0.08, ErrInvalidSizes},
+		{Sizes{}, 1, 2, 0, ErrInvalidTolerance},
+	}
+	for _, tt := range tests {
+		if _, err := tt.sizes.Widths(tt.minDPR, tt.maxDPR, tt.tolerance); !errors.Is(err, tt.want) {
+			t.Errorf("%s: Widths(%v, %v, %v) = %v; want %v", tt.sizes, tt.minDPR, tt.maxDPR, tt.tolerance, err, tt.want)
+		}
+	}
+}
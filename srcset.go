@@ -2,14 +2,15 @@ package imageboss
 
 import (
 	"math"
+	"sort"
 	"strconv"
 	"strings"
 )
 
 const (
-	defaultMinWidth   = 100
-	defaultMaxWidth   = 8192
-	defaultTolerance  = 0.08
+	defaultMinWidth  = 100
+	defaultMaxWidth  = 8192
+	defaultTolerance = 0.08
 )
 
 // DefaultWidths is the default list of widths from TargetWidths(100, 8192, 0.08).
@@ -19,12 +20,25 @@ var DefaultWidths = []int{
 	3524, 4087, 4741, 5500, 6380, 7401, 8192,
 }
 
+// DefaultDensities are the pixel densities of a density-based srcset.
+var DefaultDensities = []float64{1, 2, 3, 4, 5}
+
 // SrcsetOptions holds options for srcset generation.
 type SrcsetOptions struct {
-	MinWidth       int
-	MaxWidth       int
-	Tolerance      float64
+	MinWidth        int
+	MaxWidth        int
+	Tolerance       float64
 	VariableQuality bool
+	// WidthQuality sets the quality of each entry in a fluid srcset.
+	WidthQuality func(width int) int
+
+	// Densities, DensityQuality, IntrinsicWidth, IntrinsicHeight and
+	// CapDensities apply to density-based srcsets.
+	Densities       []float64
+	DensityQuality  func(density float64) int
+	IntrinsicWidth  int
+	IntrinsicHeight int
+	CapDensities    bool
 }
 
 // SrcsetOption configures srcset generation.
@@ -51,21 +65,100 @@ func WithTolerance(t float64) SrcsetOption {
 	}
 }
 
-// WithVariableQuality enables variable quality per DPR for fixed-width srcset.
+// WithVariableQuality enables variable quality: per density for fixed-width
+// srcsets (on by default), and per width for fluid srcsets with WithWidthQuality.
+// A quality option passed to the call or set with WithDefaultOptions takes
+// precedence.
 func WithVariableQuality(v bool) SrcsetOption {
 	return func(o *SrcsetOptions) {
 		o.VariableQuality = v
 	}
 }
 
+// WithDensities sets the pixel densities of a density-based srcset, e.g.
+// WithDensities(1, 1.5, 2, 3). Densities must be between 1 and 5 (the range of
+// the dpr option); the default is DefaultDensities.
+func WithDensities(densities ...float64) SrcsetOption {
+	return func(o *SrcsetOptions) {
+		o.Densities = append([]float64(nil), densities...)
+	}
+}
+
+// WithDensityQuality sets the quality of each entry in a density-based srcset,
+// replacing the default curve (75 at 1x down to 20 at 5x), and turns variable
+// quality on. A quality of 0 adds no quality option.
+func WithDensityQuality(fn func(density float64) int) SrcsetOption {
+	return func(o *SrcsetOptions) {
+		o.DensityQuality = fn
+		o.VariableQuality = true
+	}
+}
+
+// WithWidthQuality sets the quality of each entry in a fluid (width-based)
+// srcset from its width, and turns variable quality on. A quality of 0 adds no
+// quality option. See LinearQuality for a ready-made curve.
+func WithWidthQuality(fn func(width int) int) SrcsetOption {
+	return func(o *SrcsetOptions) {
+		o.WidthQuality = fn
+		o.VariableQuality = true
+	}
+}
+
+// LinearQuality returns a quality curve for WithWidthQuality that interpolates
+// linearly from fromQuality at fromWidth to toQuality at toWidth, and holds
+// those qualities beyond either end:
+//
+//	imageboss.WithWidthQuality(imageboss.LinearQuality(400, 80, 4000, 40))
+func LinearQuality(fromWidth, fromQuality, toWidth, toQuality int) func(width int) int {
+	return func(w int) int {
+		if fromWidth == toWidth {
+			return fromQuality
+		}
+		t := float64(w-fromWidth) / float64(toWidth-fromWidth)
+		t = math.Max(0, math.Min(1, t))
+		return int(math.Round(float64(fromQuality) + t*float64(toQuality-fromQuality)))
+	}
+}
+
+// WithIntrinsicSize sets the size of the source image; 0 means unknown. A
+// density-based srcset leaves out densities that would upscale the image
+// beyond it, as it does for densities beyond MaxDimension.
+func WithIntrinsicSize(width, height int) SrcsetOption {
+	return func(o *SrcsetOptions) {
+		o.IntrinsicWidth = width
+		o.IntrinsicHeight = height
+	}
+}
+
+// WithCapDensities makes a density-based srcset that leaves out densities
+// (see WithIntrinsicSize) end with an entry at the largest density that still
+// fits, e.g. 2.73x for Width(3000) and MaxDimension 8192, instead of stopping
+// at the last density that fits.
+func WithCapDensities(v bool) SrcsetOption {
+	return func(o *SrcsetOptions) {
+		o.CapDensities = v
+	}
+}
+
 // TargetWidths returns a slice of target widths between min and max with the given tolerance.
+// If the arguments are invalid it returns DefaultWidths; use ComputeTargetWidths to get the error instead.
 func TargetWidths(minWidth, maxWidth int, tolerance float64) []int {
-	r, err := validateRangeWithTolerance(minWidth, maxWidth, tolerance)
+	widths, err := ComputeTargetWidths(minWidth, maxWidth, tolerance)
 	if err != nil {
 		return DefaultWidths
 	}
+	return widths
+}
+
+// ComputeTargetWidths is like TargetWidths but returns a *ValidationError wrapping
+// ErrInvalidWidthRange or ErrInvalidTolerance for invalid arguments.
+func ComputeTargetWidths(minWidth, maxWidth int, tolerance float64) ([]int, error) {
+	r, err := validateRangeWithTolerance(minWidth, maxWidth, tolerance)
+	if err != nil {
+		return nil, err
+	}
 	if r.minWidth == r.maxWidth {
-		return []int{r.minWidth}
+		return []int{r.minWidth}, nil
 	}
 	var widths []int
 	start := float64(r.minWidth)
@@ -76,78 +169,434 @@ func TargetWidths(minWidth, maxWidth int, tolerance float64) []int {
 	if len(widths) > 0 && widths[len(widths)-1] < r.maxWidth {
 		widths = append(widths, r.maxWidth)
 	}
-	return widths
+	return widths, nil
 }
 
 // CreateSrcset generates a srcset attribute string.
-// If op has fixed dimensions (Width, Height, or Cover), a DPR-based srcset is generated.
-// Otherwise a fluid width-based srcset is generated.
+// If op has fixed dimensions (op.Size is non-zero, as for Width, Height, or Cover),
+// a DPR-based srcset is generated. Otherwise a fluid width-based srcset is generated.
 func (b *URLBuilder) CreateSrcset(path string, op Operation, options []Option, srcsetOpts ...SrcsetOption) string {
+	return b.Srcset(path, op, options, srcsetOpts...).String()
+}
+
+// Srcset is like CreateSrcset but returns the entries rather than the
+// attribute string.
+func (b *URLBuilder) Srcset(path string, op Operation, options []Option, srcsetOpts ...SrcsetOption) Srcset {
+	b = b.snapshot()
+	srcset, _ := b.srcset(path, op, options, newSrcsetOptions(b.config().srcsetDefaults, srcsetOpts))
+	return srcset
+}
+
+// srcset builds the srcset for Srcset. On a signing error it returns the
+// srcset with unsigned URLs and the error.
+func (b *URLBuilder) srcset(path string, op Operation, options []Option, opts SrcsetOptions) (Srcset, error) {
+	// Fixed dimensions → DPR-based srcset
+	if w, h := op.Size(); w > 0 || h > 0 {
+		return b.buildSrcsetDPR(path, op, options, opts)
+	}
+
+	// Fluid width-based srcset (widths from TargetWidths, operation from op.ResizeToWidth)
+	widths := TargetWidths(opts.MinWidth, opts.MaxWidth, opts.Tolerance)
+	return b.srcsetFromWidths(path, op, options, widths, opts)
+}
+
+// BuildSrcset is like CreateSrcset but validates its arguments first: the path,
+// operation and options as in BuildURL and, for fluid srcsets, the width range
+// and tolerance. Both widths must be between 1 and MaxDimension.
+func (b *URLBuilder) BuildSrcset(path string, op Operation, options []Option, srcsetOpts ...SrcsetOption) (string, error) {
+	b = b.snapshot()
+	opts := newSrcsetOptions(b.config().srcsetDefaults, srcsetOpts)
+	if err := validateSrcsetArgs(path, op, options, opts); err != nil {
+		return "", err
+	}
+	srcset, err := b.srcset(path, op, options, opts)
+	if err != nil {
+		return "", err
+	}
+	return srcset.String(), nil
+}
+
+// validateSrcsetArgs checks the arguments of BuildSrcset without building any
+// URLs, so callers that render the srcset themselves sign each URL only once.
+func validateSrcsetArgs(path string, op Operation, options []Option, opts SrcsetOptions) error {
+	if err := validateURLArgs(path, op, options); err != nil {
+		return err
+	}
+	if _, err := validateRangeWithTolerance(opts.MinWidth, opts.MaxWidth, opts.Tolerance); err != nil {
+		return err
+	}
+	if err := validateDimension("maxWidth", opts.MaxWidth); err != nil {
+		return err
+	}
+	return validateDensityOptions(opts)
+}
+
+// validateDensityOptions checks the density and intrinsic size options.
+func validateDensityOptions(opts SrcsetOptions) error {
+	if len(opts.Densities) == 0 {
+		return invalid(ErrInvalidDensity, "densities", opts.Densities, "cannot be empty")
+	}
+	for _, d := range opts.Densities {
+		if !(d >= 1 && d <= 5) {
+			return invalid(ErrInvalidDensity, "density", d, "out of range 1–5")
+		}
+	}
+	if opts.IntrinsicWidth < 0 {
+		return invalid(ErrInvalidDimension, "intrinsicWidth", opts.IntrinsicWidth, "")
+	}
+	if opts.IntrinsicHeight < 0 {
+		return invalid(ErrInvalidDimension, "intrinsicHeight", opts.IntrinsicHeight, "")
+	}
+	return nil
+}
+
+// newSrcsetOptions returns the default SrcsetOptions with the builder's
+// srcset defaults and then the per-call fns applied.
+func newSrcsetOptions(defaults, fns []SrcsetOption) SrcsetOptions {
 	opts := SrcsetOptions{
 		MinWidth:        defaultMinWidth,
 		MaxWidth:        defaultMaxWidth,
 		Tolerance:       defaultTolerance,
 		VariableQuality: true,
+		Densities:       DefaultDensities,
 	}
-	for _, fn := range srcsetOpts {
+	for _, fn := range defaults {
 		fn(&opts)
 	}
+	for _, fn := range fns {
+		fn(&opts)
+	}
+	return opts
+}
 
-	// Fixed dimensions → DPR-based srcset
-	if op.kind == "width" && op.width > 0 {
-		return b.buildSrcsetDPR(path, Width(op.width), options, opts.VariableQuality)
+// CreateSrcsetFromWidths builds a srcset with the given widths (fluid-width).
+// Each entry uses op.ResizeToWidth(w). Of srcsetOpts, only WithWidthQuality
+// and WithVariableQuality apply.
+func (b *URLBuilder) CreateSrcsetFromWidths(path string, op Operation, options []Option, widths []int, srcsetOpts ...SrcsetOption) string {
+	return b.SrcsetFromWidths(path, op, options, widths, srcsetOpts...).String()
+}
+
+// SrcsetFromWidths is like CreateSrcsetFromWidths but returns the entries.
+func (b *URLBuilder) SrcsetFromWidths(path string, op Operation, options []Option, widths []int, srcsetOpts ...SrcsetOption) Srcset {
+	b = b.snapshot()
+	srcset, _ := b.srcsetFromWidths(path, op, options, widths, newSrcsetOptions(b.config().srcsetDefaults, srcsetOpts))
+	return srcset
+}
+
+// srcsetFromWidths builds a width-based srcset. On a signing error it returns
+// the srcset with unsigned URLs and the first error.
+func (b *URLBuilder) srcsetFromWidths(path string, op Operation, options []Option, widths []int, opts SrcsetOptions) (Srcset, error) {
+	if len(widths) == 0 {
+		return nil, nil
 	}
-	if op.kind == "height" && op.height > 0 {
-		return b.buildSrcsetDPR(path, Height(op.height), options, opts.VariableQuality)
+	c := b.config()
+	variable := opts.VariableQuality && opts.WidthQuality != nil && !hasOption(mergeOptions(c.defaultOptions, options), "quality")
+	srcset := make(Srcset, 0, len(widths))
+	var firstErr error
+	for _, w := range widths {
+		entryOpts := options
+		if variable {
+			if q := opts.WidthQuality(w); q > 0 {
+				entryOpts = append(append([]Option(nil), options...), Quality(q))
+			}
+		}
+		e, err := c.srcsetEntry(path, op.ResizeToWidth(w), entryOpts)
+		if firstErr == nil {
+			firstErr = err
+		}
+		e.Width = w
+		srcset = append(srcset, e)
 	}
-	if op.kind == "cover" && op.width > 0 && op.height > 0 {
-		return b.buildSrcsetDPR(path, op, options, opts.VariableQuality)
+	return srcset, firstErr
+}
+
+// buildSrcsetDPR builds a density-based srcset for op. Each entry above 1x
+// adds a dpr option, so ImageBoss multiplies the output dimensions. On a
+// signing error it returns the srcset with unsigned URLs and the first error.
+func (b *URLBuilder) buildSrcsetDPR(path string, op Operation, options []Option, opts SrcsetOptions) (Srcset, error) {
+	quality := opts.DensityQuality
+	if quality == nil {
+		quality = defaultDensityQuality
+	}
+	c := b.config()
+	variable := opts.VariableQuality && !hasOption(mergeOptions(c.defaultOptions, options), "quality")
+	var srcset Srcset
+	var firstErr error
+	for _, d := range densityLadder(op, opts) {
+		var entryOpts []Option
+		entryOpts = append(entryOpts, options...)
+		if d != 1 {
+			entryOpts = append(entryOpts, DPR(d))
+		}
+		if variable {
+			if q := quality(d); q > 0 {
+				entryOpts = append(entryOpts, Quality(q))
+			}
+		}
+		e, err := c.srcsetEntry(path, op, entryOpts)
+		if firstErr == nil {
+			firstErr = err
+		}
+		e.Density = d
+		srcset = append(srcset, e)
 	}
+	return srcset, firstErr
+}
 
-	// Fluid width-based srcset (use Width as kind; actual widths from TargetWidths)
-	widths := TargetWidths(opts.MinWidth, opts.MaxWidth, opts.Tolerance)
-	return b.CreateSrcsetFromWidths(path, Width(opts.MinWidth), options, widths)
+// densityLadder returns opts.Densities in ascending order without the densities
+// that would make op's output exceed MaxDimension or the intrinsic size, plus
+// the largest density that fits if opts.CapDensities is set. Densities up to 1
+// are always kept.
+func densityLadder(op Operation, opts SrcsetOptions) []float64 {
+	limit := math.Inf(1)
+	w, h := op.Size()
+	for _, axis := range [][2]int{{w, opts.IntrinsicWidth}, {h, opts.IntrinsicHeight}} {
+		size, intrinsic := axis[0], axis[1]
+		if size <= 0 {
+			continue
+		}
+		maxSize := MaxDimension
+		if intrinsic > 0 && intrinsic < maxSize {
+			maxSize = intrinsic
+		}
+		limit = math.Min(limit, float64(maxSize)/float64(size))
+	}
+
+	densities := append([]float64(nil), opts.Densities...)
+	sort.Float64s(densities)
+	var ladder []float64
+	for _, d := range densities {
+		if d <= 1 || d <= limit {
+			ladder = append(ladder, d)
+		}
+	}
+	if opts.CapDensities && len(ladder) < len(densities) {
+		capped := math.Floor(limit*100) / 100
+		if capped > 1 && (len(ladder) == 0 || capped > ladder[len(ladder)-1]) {
+			ladder = append(ladder, capped)
+		}
+	}
+	return ladder
 }
 
-// CreateSrcsetFromWidths builds a srcset with the given widths (fluid-width).
-func (b *URLBuilder) CreateSrcsetFromWidths(path string, op Operation, options []Option, widths []int) string {
-	if len(widths) == 0 {
-		return ""
+// densityQualities is the default quality at each whole density.
+var densityQualities = []float64{75, 50, 35, 23, 20} // 1x–5x
+
+// defaultDensityQuality interpolates densityQualities linearly.
+func defaultDensityQuality(d float64) int {
+	switch {
+	case d <= 1:
+		return int(densityQualities[0])
+	case d >= 5:
+		return int(densityQualities[4])
 	}
-	var entries []string
-	for _, w := range widths {
-		var segOp Operation
-		switch op.kind {
-		case "width":
-			segOp = Width(w)
-		case "height":
-			segOp = Height(w)
-		case "cover":
-			segOp = Cover(op.width, op.height)
-			if op.mode != "" {
-				segOp = CoverMode(op.width, op.height, op.mode)
+	i := int(d) - 1
+	frac := d - math.Floor(d)
+	return int(math.Round(densityQualities[i] + frac*(densityQualities[i+1]-densityQualities[i])))
+}
+
+// srcsetEntry returns the entry for one URL, without its descriptor. On a
+// signing error it returns the entry with the unsigned URL and the error.
+func (c *builderConfig) srcsetEntry(path string, op Operation, options []Option) (SrcsetEntry, error) {
+	urlStr, err := c.createURL(path, op, options)
+	return SrcsetEntry{
+		URL:       urlStr,
+		Operation: op,
+		Quality:   optionQuality(mergeOptions(c.defaultOptions, options)),
+	}, err
+}
+
+// hasOption reports whether options include one with key.
+func hasOption(options []Option, key string) bool {
+	for _, o := range options {
+		if optionKey(o) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// optionQuality returns the value of the last quality option, or 0.
+func optionQuality(options []Option) int {
+	q := 0
+	for _, o := range options {
+		if key, value, _ := strings.Cut(o.PathSegment(), ":"); key == "quality" {
+			if n, err := strconv.Atoi(value); err == nil {
+				q = n
 			}
-		default:
-			segOp = Width(w)
 		}
-		urlStr := b.CreateURL(path, segOp, options...)
-		entries = append(entries, urlStr+" "+strconv.Itoa(w)+"w")
 	}
-	return strings.Join(entries, ",\n")
+	return q
+}
+
+// Srcset is a parsed or generated srcset: one entry per candidate image. All
+// entries use width descriptors ("700w") or all use density descriptors ("2x").
+type Srcset []SrcsetEntry
+
+// SrcsetEntry is one candidate in a srcset.
+type SrcsetEntry struct {
+	URL string
+	// Width is the width descriptor in pixels ("700w"), or 0 for a density descriptor.
+	Width int
+	// Density is the pixel density descriptor ("2x"), or 0 for a width descriptor.
+	Density float64
+	// Operation is the ImageBoss operation of URL, or nil if ParseSrcset could
+	// not parse URL as an ImageBoss URL.
+	Operation Operation
+	// Quality is the value of the entry's quality option, or 0 if it has none.
+	Quality int
+}
+
+// Descriptor returns the entry's descriptor, e.g. "700w" or "2x".
+func (e SrcsetEntry) Descriptor() string {
+	if e.Width > 0 {
+		return strconv.Itoa(e.Width) + "w"
+	}
+	return strconv.FormatFloat(e.Density, 'f', -1, 64) + "x"
+}
+
+// String returns the entry as it appears in a srcset attribute: "URL descriptor".
+func (e SrcsetEntry) String() string {
+	return e.URL + " " + e.Descriptor()
+}
+
+// PixelWidth returns the width of the entry's image in pixels: Width for a
+// width descriptor, or Density times the operation's width for a density
+// descriptor (0 if the operation has no fixed width).
+func (e SrcsetEntry) PixelWidth() int {
+	if e.Width > 0 {
+		return e.Width
+	}
+	if e.Operation == nil {
+		return 0
+	}
+	w, _ := e.Operation.Size()
+	return int(math.Round(float64(w) * e.Density))
+}
+
+// String returns the srcset attribute value with one entry per line, as
+// CreateSrcset does.
+func (s Srcset) String() string {
+	return s.join(",\n")
+}
+
+// Compact returns the srcset attribute value on a single line.
+func (s Srcset) Compact() string {
+	return s.join(", ")
+}
+
+func (s Srcset) join(sep string) string {
+	entries := make([]string, len(s))
+	for i, e := range s {
+		entries[i] = e.String()
+	}
+	return strings.Join(entries, sep)
+}
+
+// Largest returns the entry with the largest width or density descriptor.
+// It returns false if s is empty.
+func (s Srcset) Largest() (SrcsetEntry, bool) {
+	return s.pick(func(a, b SrcsetEntry) bool { return a.size() > b.size() })
+}
+
+// Smallest returns the entry with the smallest width or density descriptor.
+// It returns false if s is empty.
+func (s Srcset) Smallest() (SrcsetEntry, bool) {
+	return s.pick(func(a, b SrcsetEntry) bool { return a.size() < b.size() })
+}
+
+// ClosestTo returns the smallest entry at least width pixels wide (see
+// PixelWidth), or the largest entry if none is wide enough, e.g. for an
+// og:image or a fallback src. It returns false if s is empty.
+func (s Srcset) ClosestTo(width int) (SrcsetEntry, bool) {
+	best, ok := s.Largest()
+	for _, e := range s {
+		if w := e.PixelWidth(); w >= width && w < best.PixelWidth() {
+			best = e
+		}
+	}
+	return best, ok
 }
 
-func (b *URLBuilder) buildSrcsetDPR(path string, op Operation, options []Option, variableQuality bool) string {
-	dprQualities := map[string]string{"1": "75", "2": "50", "3": "35", "4": "23", "5": "20"}
-	var entries []string
-	for i := 1; i <= 5; i++ {
-		ratio := strconv.Itoa(i)
-		var opts []Option
-		opts = append(opts, options...)
-		if variableQuality {
-			opts = append(opts, Opt("quality", dprQualities[ratio]))
+// pick returns the first entry that no other entry is better than.
+func (s Srcset) pick(better func(a, b SrcsetEntry) bool) (SrcsetEntry, bool) {
+	if len(s) == 0 {
+		return SrcsetEntry{}, false
+	}
+	best := s[0]
+	for _, e := range s[1:] {
+		if better(e, best) {
+			best = e
+		}
+	}
+	return best, true
+}
+
+// size returns the descriptor value used to order entries.
+func (e SrcsetEntry) size() float64 {
+	if e.Width > 0 {
+		return float64(e.Width)
+	}
+	return e.Density
+}
+
+// ParseSrcset parses a srcset attribute value, e.g. one produced by
+// CreateSrcset or written by hand. A candidate without a descriptor is 1x.
+// Entries whose URL is an ImageBoss URL (see ParseURL) have their Operation
+// and Quality set. It returns a *ValidationError wrapping ErrInvalidSrcset for
+// an invalid descriptor or a srcset mixing width and density descriptors.
+func ParseSrcset(s string) (Srcset, error) {
+	var srcset Srcset
+	for rest := s; ; {
+		rest = strings.TrimLeft(rest, " \t\n\r\f,")
+		if rest == "" {
+			break
+		}
+		var urlStr, descriptor string
+		end := strings.IndexAny(rest, " \t\n\r\f")
+		if end < 0 {
+			end = len(rest)
 		}
-		urlStr := b.CreateURL(path, op, opts...)
-		entries = append(entries, urlStr+" "+ratio+"x")
+		urlStr, rest = rest[:end], rest[end:]
+		if trimmed := strings.TrimRight(urlStr, ","); trimmed != urlStr {
+			urlStr = trimmed // "url," has no descriptor
+		} else {
+			descriptor, rest, _ = strings.Cut(rest, ",")
+		}
+		e, err := parseSrcsetEntry(urlStr, strings.TrimSpace(descriptor))
+		if err != nil {
+			return nil, err
+		}
+		if len(srcset) > 0 && (srcset[0].Width > 0) != (e.Width > 0) {
+			return nil, invalid(ErrInvalidSrcset, "descriptor", e.Descriptor(), "mixes width and density descriptors")
+		}
+		srcset = append(srcset, e)
+	}
+	return srcset, nil
+}
+
+func parseSrcsetEntry(urlStr, descriptor string) (SrcsetEntry, error) {
+	e := SrcsetEntry{URL: urlStr, Density: 1}
+	switch {
+	case descriptor == "":
+	case strings.HasSuffix(descriptor, "w"):
+		w, err := strconv.Atoi(strings.TrimSuffix(descriptor, "w"))
+		if err != nil || w <= 0 {
+			return e, invalid(ErrInvalidSrcset, "descriptor", descriptor, "want a positive integer width")
+		}
+		e.Width, e.Density = w, 0
+	case strings.HasSuffix(descriptor, "x"):
+		d, err := strconv.ParseFloat(strings.TrimSuffix(descriptor, "x"), 64)
+		if err != nil || d <= 0 || math.IsInf(d, 0) {
+			return e, invalid(ErrInvalidSrcset, "descriptor", descriptor, "want a positive density")
+		}
+		e.Density = d
+	default:
+		return e, invalid(ErrInvalidSrcset, "descriptor", descriptor, "want Nw or Nx")
+	}
+	if p, err := ParseURL(urlStr); err == nil {
+		e.Operation = p.Operation
+		e.Quality = optionQuality(p.Options)
 	}
-	return strings.Join(entries, ",\n")
+	return e, nil
 }
@@ -1,6 +1,7 @@
 package imageboss
 
 import (
+	"errors"
 	"strings"
 	"testing"
 )
@@ -74,3 +75,292 @@ func TestCreateSrcset_EmptyWidths(t *testing.T) {
 		t.Errorf("CreateSrcsetFromWidths(nil widths) = %q; want \"\"", got)
 	}
 }
+
+func TestBuildSrcset(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	got, err := b.BuildSrcset("image.png", CDN(), nil, WithMinWidth(100), WithMaxWidth(380))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := b.CreateSrcset("image.png", CDN(), nil, WithMinWidth(100), WithMaxWidth(380)); got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+
+	tests := []struct {
+		path       string
+		op         Operation
+		srcsetOpts []SrcsetOption
+	}{
+		{"", CDN(), nil},
+		{"image.png", Width(-1), nil},
+		{"image.png", CDN(), []SrcsetOption{WithMinWidth(0)}},
+		{"image.png", CDN(), []SrcsetOption{WithMinWidth(500), WithMaxWidth(100)}},
+		{"image.png", CDN(), []SrcsetOption{WithMaxWidth(MaxDimension + 1)}},
+		{"image.png", CDN(), []SrcsetOption{WithTolerance(0)}},
+	}
+	for _, tt := range tests {
+		if _, err := b.BuildSrcset(tt.path, tt.op, nil, tt.srcsetOpts...); err == nil {
+			t.Errorf("BuildSrcset(%q, %+v) expected error", tt.path, tt.op)
+		}
+	}
+}
+
+func TestComputeTargetWidths(t *testing.T) {
+	got, err := ComputeTargetWidths(100, 380, 0.08)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := TargetWidths(100, 380, 0.08); len(got) != len(want) {
+		t.Errorf("ComputeTargetWidths = %v; want %v", got, want)
+	}
+	if _, err := ComputeTargetWidths(200, 100, 0.08); !errors.Is(err, ErrInvalidWidthRange) {
+		t.Errorf("ComputeTargetWidths(200, 100) err = %v; want ErrInvalidWidthRange", err)
+	}
+	// TargetWidths keeps falling back to DefaultWidths.
+	if got := TargetWidths(0, 100, 0.08); len(got) != len(DefaultWidths) {
+		t.Errorf("TargetWidths(invalid) = %v; want DefaultWidths", got)
+	}
+}
+
+func TestSrcset_Entries(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	fluid := b.Srcset("image.jpg", CDN(), []Option{Quality(60)}, WithMinWidth(100), WithMaxWidth(380))
+	if got, want := fluid.String(), b.CreateSrcset("image.jpg", CDN(), []Option{Quality(60)}, WithMinWidth(100), WithMaxWidth(380)); got != want {
+		t.Errorf("String() differs from CreateSrcset:\n%s\n%s", got, want)
+	}
+	if len(fluid) != 10 {
+		t.Fatalf("got %d entries; want 10", len(fluid))
+	}
+	e := fluid[1]
+	if e.Width != 116 || e.Density != 0 || e.Quality != 60 || e.Operation.Dimensions() != "116" {
+		t.Errorf("entry = %+v", e)
+	}
+	if got := e.String(); got != "https://img.imageboss.me/demo/width/116/quality:60/image.jpg 116w" {
+		t.Errorf("entry String() = %s", got)
+	}
+	if got := fluid[:2].Compact(); got != fluid[0].String()+", "+fluid[1].String() {
+		t.Errorf("Compact() = %s", got)
+	}
+
+	fixed := b.Srcset("image.jpg", Width(400), nil)
+	if len(fixed) != 5 || fixed[1].Density != 2 || fixed[1].Quality != 50 || fixed[1].Descriptor() != "2x" {
+		t.Errorf("fixed entry = %+v", fixed[1])
+	}
+	if got := fixed[2].PixelWidth(); got != 1200 {
+		t.Errorf("PixelWidth() = %d; want 1200", got)
+	}
+}
+
+func TestSrcset_Pick(t *testing.T) {
+	s := Srcset{{URL: "b", Width: 200}, {URL: "a", Width: 100}, {URL: "c", Width: 400}}
+	if e, ok := s.Largest(); !ok || e.URL != "c" {
+		t.Errorf("Largest() = %v, %v", e, ok)
+	}
+	if e, ok := s.Smallest(); !ok || e.URL != "a" {
+		t.Errorf("Smallest() = %v, %v", e, ok)
+	}
+	for width, want := range map[int]string{50: "a", 100: "a", 150: "b", 300: "c", 1000: "c"} {
+		if e, _ := s.ClosestTo(width); e.URL != want {
+			t.Errorf("ClosestTo(%d) = %s; want %s", width, e.URL, want)
+		}
+	}
+	if _, ok := Srcset(nil).Largest(); ok {
+		t.Error("Largest() of empty srcset = ok")
+	}
+}
+
+func TestParseSrcset(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	want := b.Srcset("image.jpg", Cover(300, 200), nil)
+	for _, s := range []string{want.String(), want.Compact()} {
+		got, err := ParseSrcset(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("got %d entries; want %d", len(got), len(want))
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("entry %d = %+v; want %+v", i, got[i], want[i])
+			}
+		}
+	}
+
+	got, err := ParseSrcset(" a.jpg, https://img.imageboss.me/demo/cdn/fill-color:fff,000/b.jpg 1.5x,c.jpg 2x ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[0].URL != "a.jpg" || got[0].Density != 1 || got[0].Operation != nil ||
+		got[1].URL != "https://img.imageboss.me/demo/cdn/fill-color:fff,000/b.jpg" || got[1].Density != 1.5 || got[1].Operation == nil ||
+		got[2].URL != "c.jpg" {
+		t.Errorf("ParseSrcset = %+v", got)
+	}
+}
+
+func TestParseSrcset_Invalid(t *testing.T) {
+	for _, s := range []string{"a.jpg 100", "a.jpg 0w", "a.jpg -1x", "a.jpg 100w, b.jpg 2x"} {
+		if _, err := ParseSrcset(s); !errors.Is(err, ErrInvalidSrcset) {
+			t.Errorf("ParseSrcset(%q) = %v; want ErrInvalidSrcset", s, err)
+		}
+	}
+}
+
+func TestCreateSrcsetFromWidths_Cover(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	got := b.CreateSrcsetFromWidths("image.jpg", CoverMode(800, 450, "center"), nil, []int{400, 800, 1600})
+	want := "https://img.imageboss.me/demo/cover:center/400x225/image.jpg 400w,\n" +
+		"https://img.imageboss.me/demo/cover:center/800x450/image.jpg 800w,\n" +
+		"https://img.imageboss.me/demo/cover:center/1600x900/image.jpg 1600w"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestCreateSrcset_Densities(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	got := b.Srcset("a.jpg", Width(400), nil, WithDensities(1, 1.5, 2, 3))
+	want := []string{
+		"https://img.imageboss.me/demo/width/400/quality:75/a.jpg 1x",
+		"https://img.imageboss.me/demo/width/400/dpr:1.5/quality:63/a.jpg 1.5x",
+		"https://img.imageboss.me/demo/width/400/dpr:2/quality:50/a.jpg 2x",
+		"https://img.imageboss.me/demo/width/400/dpr:3/quality:35/a.jpg 3x",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d entries; want %d:\n%s", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].String() != want[i] {
+			t.Errorf("entry %d:\ngot:  %s\nwant: %s", i, got[i], want[i])
+		}
+	}
+
+	custom := b.Srcset("a.jpg", Width(400), nil, WithDensities(1, 2), WithDensityQuality(func(d float64) int {
+		if d > 1 {
+			return 40
+		}
+		return 0
+	}))
+	if custom[0].Quality != 0 || custom[1].Quality != 40 {
+		t.Errorf("custom quality = %d, %d; want 0, 40", custom[0].Quality, custom[1].Quality)
+	}
+	plain := b.Srcset("a.jpg", Width(400), nil, WithDensities(2), WithVariableQuality(false))
+	if len(plain) != 1 || plain[0].String() != "https://img.imageboss.me/demo/width/400/dpr:2/a.jpg 2x" {
+		t.Errorf("without variable quality: %s", plain)
+	}
+}
+
+func TestCreateSrcset_DensityLimits(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	tests := []struct {
+		name       string
+		op         Operation
+		srcsetOpts []SrcsetOption
+		want       []float64
+	}{
+		{"max dimension", Width(3000), nil, []float64{1, 2}},
+		{"capped", Width(3000), []SrcsetOption{WithCapDensities(true)}, []float64{1, 2, 2.73}},
+		{"height", Height(4000), nil, []float64{1, 2}},
+		{"cover height limits", Cover(1000, 3000), nil, []float64{1, 2}},
+		{"intrinsic width", Width(800), []SrcsetOption{WithIntrinsicSize(2000, 0)}, []float64{1, 2}},
+		{"intrinsic capped", Width(800), []SrcsetOption{WithIntrinsicSize(2000, 0), WithCapDensities(true)}, []float64{1, 2, 2.5}},
+		{"intrinsic height", Cover(400, 300), []SrcsetOption{WithIntrinsicSize(0, 600)}, []float64{1, 2}},
+		{"exact fit not capped", Width(1000), []SrcsetOption{WithIntrinsicSize(2000, 0), WithCapDensities(true)}, []float64{1, 2}},
+		{"1x always kept", Width(800), []SrcsetOption{WithIntrinsicSize(500, 0)}, []float64{1}},
+		{"unsorted", Width(100), []SrcsetOption{WithDensities(3, 1, 2)}, []float64{1, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := b.Srcset("a.jpg", tt.op, nil, tt.srcsetOpts...)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d entries; want densities %v:\n%s", len(got), tt.want, got)
+			}
+			for i, d := range tt.want {
+				if got[i].Density != d {
+					t.Errorf("entry %d density = %v; want %v", i, got[i].Density, d)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildSrcset_Densities(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	for _, opt := range []SrcsetOption{WithDensities(), WithDensities(1, 6), WithDensities(0.5), WithIntrinsicSize(-1, 0)} {
+		_, err := b.BuildSrcset("a.jpg", Width(100), nil, opt)
+		if !errors.Is(err, ErrInvalidDensity) && !errors.Is(err, ErrInvalidDimension) {
+			t.Errorf("BuildSrcset err = %v; want ErrInvalidDensity or ErrInvalidDimension", err)
+		}
+	}
+}
+
+func TestLinearQuality(t *testing.T) {
+	q := LinearQuality(400, 80, 4000, 40)
+	for w, want := range map[int]int{100: 80, 400: 80, 2200: 60, 4000: 40, 8000: 40} {
+		if got := q(w); got != want {
+			t.Errorf("q(%d) = %d; want %d", w, got, want)
+		}
+	}
+	if got := LinearQuality(500, 70, 500, 30)(900); got != 70 {
+		t.Errorf("single-width curve = %d; want 70", got)
+	}
+}
+
+func TestCreateSrcset_WidthQuality(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	got := b.CreateSrcsetFromWidths("a.jpg", CDN(), nil, []int{400, 2200, 4000}, WithWidthQuality(LinearQuality(400, 80, 4000, 40)))
+	want := "https://img.imageboss.me/demo/width/400/quality:80/a.jpg 400w,\n" +
+		"https://img.imageboss.me/demo/width/2200/quality:60/a.jpg 2200w,\n" +
+		"https://img.imageboss.me/demo/width/4000/quality:40/a.jpg 4000w"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+
+	fluid := b.Srcset("a.jpg", CDN(), nil, WithMinWidth(100), WithMaxWidth(380), WithWidthQuality(func(w int) int { return w / 10 }))
+	for _, e := range fluid {
+		if e.Quality != e.Width/10 {
+			t.Errorf("entry %s: quality %d; want %d", e.Descriptor(), e.Quality, e.Width/10)
+		}
+	}
+
+	// Off without a curve, with WithVariableQuality(false), or when the call sets quality.
+	for _, s := range []Srcset{
+		b.SrcsetFromWidths("a.jpg", CDN(), nil, []int{400}),
+		b.SrcsetFromWidths("a.jpg", CDN(), nil, []int{400}, WithWidthQuality(func(int) int { return 50 }), WithVariableQuality(false)),
+		b.SrcsetFromWidths("a.jpg", CDN(), []Option{Quality(90)}, []int{400}, WithWidthQuality(func(int) int { return 50 })),
+	} {
+		if strings.Contains(s.String(), "quality:50") {
+			t.Errorf("unexpected width quality: %s", s)
+		}
+	}
+	if got := b.CreateSrcset("a.jpg", Width(400), []Option{Quality(90)}); strings.Count(got, "quality:") != 5 {
+		t.Errorf("per-call quality should replace density quality:\n%s", got)
+	}
+}
+
+func TestCreateSrcset_DefaultQuality(t *testing.T) {
+	b := MustNewURLBuilder("demo", WithDefaultOptions(Quality(80)))
+	for _, s := range []Srcset{
+		b.Srcset("a.jpg", Width(400), nil),
+		b.SrcsetFromWidths("a.jpg", CDN(), nil, []int{400, 800}, WithWidthQuality(func(int) int { return 50 })),
+	} {
+		for _, e := range s {
+			if e.Quality != 80 {
+				t.Errorf("entry %s: quality %d; want default 80", e.Descriptor(), e.Quality)
+			}
+		}
+	}
+	// NoDefaults drops the default, so the curve applies again.
+	if s := b.Srcset("a.jpg", Width(400), []Option{NoDefaults("quality")}); s[len(s)-1].Quality == 80 {
+		t.Errorf("NoDefaults(quality): %s", s)
+	}
+}
+
+func TestCreateSrcsetFromWidths_Height(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	got := b.CreateSrcsetFromWidths("a.jpg", Height(300), nil, []int{100, 200}, WithVariableQuality(false))
+	want := "https://img.imageboss.me/demo/width/100/a.jpg 100w,\nhttps://img.imageboss.me/demo/width/200/a.jpg 200w"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+}
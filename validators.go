@@ -1,8 +1,7 @@
 package imageboss
 
 import (
-	"errors"
-	"fmt"
+	"net/url"
 	"regexp"
 	"strings"
 )
@@ -14,18 +13,51 @@ var (
 func validateSource(source string) (string, error) {
 	s := strings.TrimSpace(source)
 	if s == "" {
-		return "", errors.New("imageboss: source cannot be empty")
+		return "", invalid(ErrInvalidSource, "source", source, "cannot be empty")
 	}
 	if !sourceRegexp.MatchString(s) {
-		return "", fmt.Errorf("imageboss: invalid source %q", source)
+		return "", invalid(ErrInvalidSource, "source", source, "")
 	}
 	return s, nil
 }
 
-// validateDimension ensures width/height are positive.
-func validateDimension(d int) error {
+// validateBaseURL checks that baseURL is an absolute http(s) or protocol-relative
+// URL with a host, and returns its host and path prefix without the scheme.
+func validateBaseURL(baseURL string) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", invalid(ErrInvalidBaseURL, "baseURL", baseURL, err.Error())
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "":
+	default:
+		return "", invalid(ErrInvalidBaseURL, "baseURL", baseURL, "must use http or https")
+	}
+	if u.Host == "" {
+		return "", invalid(ErrInvalidBaseURL, "baseURL", baseURL, "has no host")
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return "", invalid(ErrInvalidBaseURL, "baseURL", baseURL, "must not have a query or fragment")
+	}
+	return u.Host + strings.TrimSuffix(u.EscapedPath(), "/"), nil
+}
+
+// validateDimension ensures width/height are positive and at most MaxDimension.
+// field names the dimension in the returned error.
+func validateDimension(field string, d int) error {
 	if d <= 0 {
-		return errors.New("imageboss: width and height must be positive")
+		return invalid(ErrInvalidDimension, field, d, "")
+	}
+	if d > MaxDimension {
+		return invalid(ErrDimensionTooLarge, field, d, "maximum is %d", MaxDimension)
+	}
+	return nil
+}
+
+// validatePath ensures the sanitized image path is not empty.
+func validatePath(path string) error {
+	if strings.Trim(strings.TrimSpace(path), "/") == "" {
+		return invalid(ErrEmptyPath, "path", path, "")
 	}
 	return nil
 }
@@ -37,14 +69,14 @@ type widthRange struct {
 }
 
 func validateRangeWithTolerance(minW, maxW int, tol float64) (widthRange, error) {
-	if minW < 0 || maxW < 0 {
-		return widthRange{}, errors.New("imageboss: min and max width must be >= 0")
+	if minW < 1 {
+		return widthRange{}, invalid(ErrInvalidWidthRange, "minWidth", minW, "must be >= 1")
 	}
 	if maxW < minW {
-		return widthRange{}, errors.New("imageboss: maxWidth must be >= minWidth")
+		return widthRange{}, invalid(ErrInvalidWidthRange, "maxWidth", maxW, "must be >= minWidth %d", minW)
 	}
 	if tol < 0.01 {
-		return widthRange{}, errors.New("imageboss: tolerance must be >= 0.01")
+		return widthRange{}, invalid(ErrInvalidTolerance, "tolerance", tol, "must be >= 0.01")
 	}
 	return widthRange{minW, maxW, tol}, nil
 }
@@ -0,0 +1,98 @@
+package imageboss
+
+// Variant is an alternative treatment of an image for users matching a media
+// condition, typically a user preference such as PrefersColorScheme("dark").
+// Picture and ArtDirection render it as <source media=…> elements ahead of
+// the default ones, so it wins when its condition matches.
+type Variant struct {
+	Media MediaCondition
+	// Path replaces the image path if set, e.g. a dark version of a logo.
+	Path string
+	// Operation replaces the operation if set.
+	Operation Operation
+	// Options are added to the call's options; an option with the same key
+	// replaces the call's, e.g. Quality(40) for reduced-data users.
+	Options []Option
+}
+
+// WithVariant adds variants to Picture and ArtDirection, in order:
+//
+//	b.Picture("logo.png", imageboss.Width(200), nil,
+//		imageboss.WithVariant(imageboss.Variant{Media: imageboss.PrefersColorScheme("dark"), Path: "logo-dark.png"}),
+//		imageboss.WithVariant(imageboss.Variant{Media: imageboss.PrefersReducedData(), Options: []imageboss.Option{imageboss.Quality(40)}}))
+func WithVariant(variants ...Variant) ImgOption {
+	return func(c *imgConfig) {
+		c.variants = append(c.variants, variants...)
+	}
+}
+
+// apply returns the path, operation and options of the variant of an image.
+func (v Variant) apply(path string, op Operation, options []Option) (string, Operation, []Option) {
+	if v.Path != "" {
+		path = v.Path
+	}
+	if v.Operation != nil {
+		op = v.Operation
+	}
+	return path, op, mergeOptions(options, v.Options)
+}
+
+// validate checks the variant of an image as BuildURL would.
+func (v Variant) validate(path string, op Operation, options []Option) error {
+	if v.Media.text == "" {
+		return invalid(ErrInvalidAttribute, "media", "", "a variant needs a media condition")
+	}
+	path, op, options = v.apply(path, op, options)
+	return validateURLArgs(path, op, options)
+}
+
+// PrefersColorScheme returns the condition "(prefers-color-scheme: scheme)",
+// where scheme is "dark" or "light".
+func PrefersColorScheme(scheme string) MediaCondition {
+	return MediaFeature("prefers-color-scheme", scheme)
+}
+
+// PrefersReducedData returns the condition "(prefers-reduced-data: reduce)".
+func PrefersReducedData() MediaCondition {
+	return MediaFeature("prefers-reduced-data", "reduce")
+}
+
+// PrefersReducedMotion returns the condition "(prefers-reduced-motion: reduce)",
+// e.g. to serve a still frame of an animation.
+func PrefersReducedMotion() MediaCondition {
+	return MediaFeature("prefers-reduced-motion", "reduce")
+}
+
+// PrefersContrast returns the condition "(prefers-contrast: value)", where
+// value is "more", "less" or "custom".
+func PrefersContrast(value string) MediaCondition {
+	return MediaFeature("prefers-contrast", value)
+}
+
+// MediaFeature returns the condition "(name: value)", or "(name)" if value is
+// empty. Sizes.Widths assumes it can match at any viewport width.
+func MediaFeature(name, value string) MediaCondition {
+	if value == "" {
+		return MediaQuery("(" + name + ")")
+	}
+	return MediaQuery("(" + name + ": " + value + ")")
+}
+
+// And returns the condition that matches when both c and other do.
+func (c MediaCondition) And(other MediaCondition) MediaCondition {
+	switch {
+	case c.text == "":
+		return other
+	case other.text == "":
+		return c
+	}
+	return MediaCondition{text: c.text + " and " + other.text, min: max(c.min, other.min), max: minPositive(c.max, other.max)}
+}
+
+// minPositive returns the smaller of a and b, ignoring zeros (unbounded).
+func minPositive(a, b int) int {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
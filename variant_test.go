@@ -0,0 +1,104 @@
+package imageboss
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMediaFeature(t *testing.T) {
+	tests := []struct {
+		cond MediaCondition
+		want string
+	}{
+		{PrefersColorScheme("dark"), "(prefers-color-scheme: dark)"},
+		{PrefersReducedData(), "(prefers-reduced-data: reduce)"},
+		{PrefersReducedMotion(), "(prefers-reduced-motion: reduce)"},
+		{PrefersContrast("more"), "(prefers-contrast: more)"},
+		{MediaFeature("monochrome", ""), "(monochrome)"},
+		{MediaMinWidth(800).And(PrefersColorScheme("dark")), "(min-width: 800px) and (prefers-color-scheme: dark)"},
+		{MediaCondition{}.And(PrefersReducedData()), "(prefers-reduced-data: reduce)"},
+	}
+	for _, tt := range tests {
+		if got := tt.cond.String(); got != tt.want {
+			t.Errorf("got %q; want %q", got, tt.want)
+		}
+	}
+	c := MediaMinWidth(400).And(MediaMaxWidth(800)).And(MediaMaxWidth(600))
+	if c.min != 400 || c.max != 600 {
+		t.Errorf("And range = %d-%d; want 400-600", c.min, c.max)
+	}
+}
+
+func TestPicture_Variants(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	got := string(b.Picture("logo.png", Width(200), []Option{Quality(80)},
+		WithSourceFormats(WebP), WithFallbackFormat(PNG),
+		WithSrcset(WithDensities(1), WithVariableQuality(false)),
+		WithVariant(
+			Variant{Media: PrefersColorScheme("dark"), Path: "logo-dark.png"},
+			Variant{Media: PrefersReducedData(), Options: []Option{Quality(40)}},
+		)))
+	want := "<picture>\n" +
+		`<source media="(prefers-color-scheme: dark)" type="image/webp" srcset="https://img.imageboss.me/demo/width/200/quality:80/format:webp/logo-dark.png 1x">` + "\n" +
+		`<source media="(prefers-color-scheme: dark)" type="image/png" srcset="https://img.imageboss.me/demo/width/200/quality:80/format:png/logo-dark.png 1x">` + "\n" +
+		`<source media="(prefers-reduced-data: reduce)" type="image/webp" srcset="https://img.imageboss.me/demo/width/200/quality:40/format:webp/logo.png 1x">` + "\n" +
+		`<source media="(prefers-reduced-data: reduce)" type="image/png" srcset="https://img.imageboss.me/demo/width/200/quality:40/format:png/logo.png 1x">` + "\n" +
+		`<source type="image/webp" srcset="https://img.imageboss.me/demo/width/200/quality:80/format:webp/logo.png 1x">` + "\n" +
+		`<img src="https://img.imageboss.me/demo/width/200/quality:80/format:png/logo.png" srcset="https://img.imageboss.me/demo/width/200/quality:80/format:png/logo.png 1x" alt="">` + "\n" +
+		"</picture>"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestArtDirection_Variants(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	p, err := b.ArtDirection("hero.jpg", []Breakpoint{
+		{Media: MediaMinWidth(1024), Operation: Cover(1600, 600)},
+		{Operation: Cover(800, 800)},
+	}, WithSrcset(WithDensities(1), WithVariableQuality(false)),
+		WithVariant(Variant{Media: PrefersColorScheme("dark"), Path: "hero-night.jpg"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var media, srcsets []string
+	for _, s := range p.Sources {
+		media = append(media, s.Media)
+		srcsets = append(srcsets, s.Srcset)
+	}
+	wantMedia := []string{
+		"(min-width: 1024px) and (prefers-color-scheme: dark)",
+		"(min-width: 1024px)",
+		"(prefers-color-scheme: dark)",
+	}
+	if strings.Join(media, "|") != strings.Join(wantMedia, "|") {
+		t.Errorf("media = %q; want %q", media, wantMedia)
+	}
+	if srcsets[0] != "https://img.imageboss.me/demo/cover/1600x600/hero-night.jpg 1x" ||
+		srcsets[2] != "https://img.imageboss.me/demo/cover/800x800/hero-night.jpg 1x" {
+		t.Errorf("srcsets = %q", srcsets)
+	}
+	if p.Img.Src != "https://img.imageboss.me/demo/cover/800x800/hero.jpg" {
+		t.Errorf("img src = %s", p.Img.Src)
+	}
+}
+
+func TestVariant_Invalid(t *testing.T) {
+	b := MustNewURLBuilder("demo")
+	for _, v := range []Variant{
+		{Path: "dark.jpg"},
+		{Media: PrefersColorScheme("dark"), Operation: Width(-1)},
+		{Media: PrefersColorScheme("dark"), Path: "/"},
+	} {
+		if _, err := b.BuildPicture("a.jpg", Width(100), nil, WithVariant(v)); err == nil {
+			t.Errorf("BuildPicture(%+v) = nil error", v)
+		}
+		if _, err := b.ArtDirection("a.jpg", []Breakpoint{{Operation: Width(100)}}, WithVariant(v)); err == nil {
+			t.Errorf("ArtDirection(%+v) = nil error", v)
+		}
+	}
+	if _, err := b.BuildPicture("a.jpg", Width(100), nil, WithVariant(Variant{})); !errors.Is(err, ErrInvalidAttribute) {
+		t.Errorf("err = %v; want ErrInvalidAttribute", err)
+	}
+}